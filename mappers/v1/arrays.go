@@ -0,0 +1,202 @@
+package sqlcmapper
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Postgres array helpers
+/////////////////////
+
+// A NULL array maps to a nil slice. NULL elements map to the zero value in
+// the plain variants and to nil in the pointer variants.
+
+func PgTextArrayToStringSlice(a pgtype.Array[pgtype.Text]) []string {
+	return pgArrayToSlice(a, func(t pgtype.Text) string { return t.String })
+}
+
+func PgTextArrayToStringPtrSlice(a pgtype.Array[pgtype.Text]) []*string {
+	return pgArrayToSlice(a, PgTextToStringPtr)
+}
+
+func PgInt4ArrayToInt32Slice(a pgtype.Array[pgtype.Int4]) []int32 {
+	return pgArrayToSlice(a, func(i pgtype.Int4) int32 { return i.Int32 })
+}
+
+func PgInt4ArrayToInt32PtrSlice(a pgtype.Array[pgtype.Int4]) []*int32 {
+	return pgArrayToSlice(a, PgInt4ToInt32Ptr)
+}
+
+func PgInt2ArrayToInt16Slice(a pgtype.Array[pgtype.Int2]) []int16 {
+	return pgArrayToSlice(a, func(i pgtype.Int2) int16 { return i.Int16 })
+}
+
+func PgInt2ArrayToInt16PtrSlice(a pgtype.Array[pgtype.Int2]) []*int16 {
+	return pgArrayToSlice(a, PgInt2ToInt16Ptr)
+}
+
+func PgInt8ArrayToInt64Slice(a pgtype.Array[pgtype.Int8]) []int64 {
+	return pgArrayToSlice(a, func(i pgtype.Int8) int64 { return i.Int64 })
+}
+
+func PgInt8ArrayToInt64PtrSlice(a pgtype.Array[pgtype.Int8]) []*int64 {
+	return pgArrayToSlice(a, PgInt8ToInt64Ptr)
+}
+
+func PgUUIDArrayToStringSlice(a pgtype.Array[pgtype.UUID]) []string {
+	return pgArrayToSlice(a, PgUUIDToString)
+}
+
+func PgUUIDArrayToStringPtrSlice(a pgtype.Array[pgtype.UUID]) []*string {
+	return pgArrayToSlice(a, func(id pgtype.UUID) *string {
+		if !id.Valid {
+			return nil
+		}
+		s := PgUUIDToString(id)
+		return &s
+	})
+}
+
+func PgDateArrayToTimeSlice(a pgtype.Array[pgtype.Date]) []time.Time {
+	return pgArrayToSlice(a, func(d pgtype.Date) time.Time {
+		if t := PgDateToTimePtr(d); t != nil {
+			return *t
+		}
+		return time.Time{}
+	})
+}
+
+func PgDateArrayToTimePtrSlice(a pgtype.Array[pgtype.Date]) []*time.Time {
+	return pgArrayToSlice(a, PgDateToTimePtr)
+}
+
+// PgBoolArrayToBoolSlice maps NULL elements to false; use
+// PgBoolArrayToBoolPtrSlice to keep them as nil. A NULL array yields nil.
+func PgBoolArrayToBoolSlice(a pgtype.Array[pgtype.Bool]) []bool {
+	return pgArrayToSlice(a, func(b pgtype.Bool) bool { return b.Bool })
+}
+
+func PgBoolArrayToBoolPtrSlice(a pgtype.Array[pgtype.Bool]) []*bool {
+	return pgArrayToSlice(a, PgBoolToBoolPtr)
+}
+
+func PgFloat8ArrayToFloat64Slice(a pgtype.Array[pgtype.Float8]) []float64 {
+	return pgArrayToSlice(a, func(f pgtype.Float8) float64 { return f.Float64 })
+}
+
+func PgFloat8ArrayToFloat64PtrSlice(a pgtype.Array[pgtype.Float8]) []*float64 {
+	return pgArrayToSlice(a, PgFloat8ToFloat64Ptr)
+}
+
+func pgArrayToSlice[E any, T any](a pgtype.Array[E], conv func(E) T) []T {
+	if !a.Valid {
+		return nil
+	}
+	out := make([]T, len(a.Elements))
+	for i, e := range a.Elements {
+		out[i] = conv(e)
+	}
+	return out
+}
+
+// setPgArrayField fills a slice model field from a pgtype.Array db value.
+// It reports false when the db value is not a supported array or the target
+// slice type doesn't match one of the helpers above.
+func setPgArrayField(field reflect.Value, dbValue interface{}) bool {
+	var out interface{}
+	switch a := dbValue.(type) {
+	case pgtype.Array[pgtype.Text]:
+		switch field.Type() {
+		case reflect.TypeOf([]string(nil)):
+			out = PgTextArrayToStringSlice(a)
+		case reflect.TypeOf([]*string(nil)):
+			out = PgTextArrayToStringPtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Int4]:
+		switch field.Type() {
+		case reflect.TypeOf([]int32(nil)):
+			out = PgInt4ArrayToInt32Slice(a)
+		case reflect.TypeOf([]*int32(nil)):
+			out = PgInt4ArrayToInt32PtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Int2]:
+		switch field.Type() {
+		case reflect.TypeOf([]int16(nil)):
+			out = PgInt2ArrayToInt16Slice(a)
+		case reflect.TypeOf([]*int16(nil)):
+			out = PgInt2ArrayToInt16PtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Int8]:
+		switch field.Type() {
+		case reflect.TypeOf([]int64(nil)):
+			out = PgInt8ArrayToInt64Slice(a)
+		case reflect.TypeOf([]*int64(nil)):
+			out = PgInt8ArrayToInt64PtrSlice(a)
+		}
+	case pgtype.Array[pgtype.UUID]:
+		switch field.Type() {
+		case reflect.TypeOf([]string(nil)):
+			out = PgUUIDArrayToStringSlice(a)
+		case reflect.TypeOf([]*string(nil)):
+			out = PgUUIDArrayToStringPtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Date]:
+		switch field.Type() {
+		case reflect.TypeOf([]time.Time(nil)):
+			out = PgDateArrayToTimeSlice(a)
+		case reflect.TypeOf([]*time.Time(nil)):
+			out = PgDateArrayToTimePtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Bool]:
+		switch field.Type() {
+		case reflect.TypeOf([]bool(nil)):
+			out = PgBoolArrayToBoolSlice(a)
+		case reflect.TypeOf([]*bool(nil)):
+			out = PgBoolArrayToBoolPtrSlice(a)
+		}
+	case pgtype.Array[pgtype.Float8]:
+		switch field.Type() {
+		case reflect.TypeOf([]float64(nil)):
+			out = PgFloat8ArrayToFloat64Slice(a)
+		case reflect.TypeOf([]*float64(nil)):
+			out = PgFloat8ArrayToFloat64PtrSlice(a)
+		}
+	}
+	if out == nil {
+		return false
+	}
+	field.Set(reflect.ValueOf(out))
+	return true
+}
+
+func supportsFixedArray(dbType, fieldType reflect.Type) bool {
+	probe := reflect.New(reflect.SliceOf(fieldType.Elem())).Elem()
+	if setPgArrayField(probe, reflect.Zero(dbType).Interface()) {
+		return true
+	}
+	return dbType.Kind() == reflect.Slice && dbType.Elem().AssignableTo(fieldType.Elem())
+}
+
+// setFixedArrayField fills a fixed-size [N]T model field from a pgtype.Array
+// or plain slice db value. Extra source elements are an error and missing ones
+// are zero-filled, unless WithStrictArrayLen requires an exact length.
+func setFixedArrayField(field, dbField reflect.Value, cfg *config) error {
+	src := reflect.New(reflect.SliceOf(field.Type().Elem())).Elem()
+	switch {
+	case setPgArrayField(src, dbField.Interface()):
+	case dbField.Kind() == reflect.Slice && dbField.Type().Elem().AssignableTo(field.Type().Elem()):
+		src = dbField
+	default:
+		return nil
+	}
+	if src.Len() > field.Len() || (cfg.strictArrayLen && src.Len() != field.Len()) {
+		return fmt.Errorf("array length %d does not fit %s", src.Len(), field.Type())
+	}
+	field.Set(reflect.Zero(field.Type()))
+	reflect.Copy(field, src)
+	return nil
+}
@@ -0,0 +1,180 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func pgArray[E any](elems ...E) pgtype.Array[E] {
+	return pgtype.Array[E]{Elements: elems, Dims: []pgtype.ArrayDimension{{Length: int32(len(elems)), LowerBound: 1}}, Valid: true}
+}
+
+type scalarArrayRow struct {
+	Days   pgtype.Array[pgtype.Date]
+	Flags  pgtype.Array[pgtype.Bool]
+	Scores pgtype.Array[pgtype.Float8]
+}
+
+type scalarArrayModel struct {
+	Days   []time.Time
+	Flags  []bool
+	Scores []float64
+}
+
+type scalarArrayPtrModel struct {
+	Days   []*time.Time
+	Flags  []*bool
+	Scores []*float64
+}
+
+func TestAutoMapScalarArraysNullElements(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	row := scalarArrayRow{
+		Days:   pgArray(pgtype.Date{Time: day, Valid: true}, pgtype.Date{}),
+		Flags:  pgArray(pgtype.Bool{Bool: true, Valid: true}, pgtype.Bool{}),
+		Scores: pgArray(pgtype.Float8{Float64: 1.5, Valid: true}, pgtype.Float8{}),
+	}
+
+	plain, err := AutoMapWithTags[scalarArrayRow, scalarArrayModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scalarArrayModel{Days: []time.Time{day, {}}, Flags: []bool{true, false}, Scores: []float64{1.5, 0}}
+	if !reflect.DeepEqual(plain, want) {
+		t.Fatalf("plain: got %+v, want %+v", plain, want)
+	}
+
+	ptrs, err := AutoMapWithTags[scalarArrayRow, scalarArrayPtrModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eqPtr(ptrs.Days[0], &day) || ptrs.Days[1] != nil ||
+		!eqPtr(ptrs.Flags[0], ptr(true)) || ptrs.Flags[1] != nil ||
+		!eqPtr(ptrs.Scores[0], ptr(1.5)) || ptrs.Scores[1] != nil {
+		t.Fatalf("pointers: got %+v", ptrs)
+	}
+
+	none, err := AutoMapWithTags[scalarArrayRow, scalarArrayPtrModel](scalarArrayRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none.Days != nil || none.Flags != nil || none.Scores != nil {
+		t.Fatalf("NULL arrays: got %+v, want nil slices", none)
+	}
+}
+
+func TestPgDateArrayInfinityIsNull(t *testing.T) {
+	a := pgArray(pgtype.Date{InfinityModifier: pgtype.Infinity, Valid: true})
+	if got := PgDateArrayToTimePtrSlice(a); len(got) != 1 || got[0] != nil {
+		t.Fatalf("pointer: got %v, want [nil]", got)
+	}
+	if got := PgDateArrayToTimeSlice(a); len(got) != 1 || !got[0].IsZero() {
+		t.Fatalf("plain: got %v, want [zero]", got)
+	}
+}
+
+type intArrayRow struct {
+	Small pgtype.Array[pgtype.Int2]
+	Big   pgtype.Array[pgtype.Int8]
+}
+
+type intArrayModel struct {
+	Small []int16
+	Big   []int64
+}
+
+type intArrayPtrModel struct {
+	Small []*int16
+	Big   []*int64
+}
+
+func TestAutoMapIntArraysNullElements(t *testing.T) {
+	row := intArrayRow{
+		Small: pgArray(pgtype.Int2{Int16: -3, Valid: true}, pgtype.Int2{}),
+		Big:   pgArray(pgtype.Int8{}, pgtype.Int8{Int64: 1 << 40, Valid: true}),
+	}
+	plain, err := AutoMapWithTags[intArrayRow, intArrayModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (intArrayModel{Small: []int16{-3, 0}, Big: []int64{0, 1 << 40}}); !reflect.DeepEqual(plain, want) {
+		t.Fatalf("plain: got %+v, want %+v", plain, want)
+	}
+
+	ptrs, err := AutoMapWithTags[intArrayRow, intArrayPtrModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eqPtr(ptrs.Small[0], ptr[int16](-3)) || ptrs.Small[1] != nil || ptrs.Big[0] != nil || !eqPtr(ptrs.Big[1], ptr[int64](1<<40)) {
+		t.Fatalf("pointers: got Small %v, Big %v", ptrs.Small, ptrs.Big)
+	}
+
+	if PgInt2ArrayToInt16PtrSlice(pgtype.Array[pgtype.Int2]{}) != nil || PgInt8ArrayToInt64Slice(pgtype.Array[pgtype.Int8]{}) != nil {
+		t.Fatal("NULL arrays should map to nil slices")
+	}
+}
+
+type fixedArrayRow struct {
+	RGB   pgtype.Array[pgtype.Float8]
+	Codes []string
+}
+
+type fixedArrayModel struct {
+	RGB   [3]float64
+	Codes [2]string
+}
+
+func TestAutoMapFixedArray(t *testing.T) {
+	row := fixedArrayRow{
+		RGB:   pgArray(pgtype.Float8{Float64: 0.1, Valid: true}, pgtype.Float8{Float64: 0.2, Valid: true}, pgtype.Float8{Float64: 0.3, Valid: true}),
+		Codes: []string{"a", "b"},
+	}
+	got, err := AutoMapWithTags[fixedArrayRow, fixedArrayModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (fixedArrayModel{RGB: [3]float64{0.1, 0.2, 0.3}, Codes: [2]string{"a", "b"}}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAutoMapFixedArrayShortSourceZeroFills(t *testing.T) {
+	row := fixedArrayRow{RGB: pgArray(pgtype.Float8{Float64: 0.5, Valid: true}), Codes: []string{"a"}}
+	got, err := AutoMapWithTags[fixedArrayRow, fixedArrayModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (fixedArrayModel{RGB: [3]float64{0.5}, Codes: [2]string{"a"}}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	dst := fixedArrayModel{RGB: [3]float64{9, 9, 9}, Codes: [2]string{"x", "y"}}
+	if err := AutoMapInto(row, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if want := (fixedArrayModel{RGB: [3]float64{0.5}, Codes: [2]string{"a"}}); dst != want {
+		t.Fatalf("AutoMapInto: got %+v, want the tail zeroed", dst)
+	}
+}
+
+func TestAutoMapFixedArrayLengthErrors(t *testing.T) {
+	short := fixedArrayRow{Codes: []string{"a"}}
+	if _, err := AutoMapWithTags[fixedArrayRow, fixedArrayModel](short, WithStrictArrayLen()); err == nil {
+		t.Fatal("WithStrictArrayLen: expected an error for a short source")
+	}
+
+	rgb := pgArray(pgtype.Float8{Valid: true}, pgtype.Float8{Valid: true}, pgtype.Float8{Valid: true})
+	long := fixedArrayRow{RGB: rgb, Codes: []string{"a", "b", "c"}}
+	for _, opts := range [][]Option{nil, {WithStrictArrayLen()}} {
+		_, err := AutoMapWithTags[fixedArrayRow, fixedArrayModel](long, opts...)
+		var mapErr *MapError
+		if !errors.As(err, &mapErr) || mapErr.Field != "Codes" || !strings.Contains(err.Error(), "array length 3 does not fit [2]string") {
+			t.Fatalf("%d options: err = %v, want a length error for Codes", len(opts), err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package sqlcmapper
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+/////////////////////
+// Bytea helpers
+/////////////////////
+
+// pgx scans bytea into []byte, with nil standing for NULL.
+
+func PgByteaToHexString(b []byte) string {
+	if b == nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func PgByteaToHexStringPtr(b []byte) *string {
+	if b == nil {
+		return nil
+	}
+	s := hex.EncodeToString(b)
+	return &s
+}
+
+func PgByteaToBase64String(b []byte) string {
+	if b == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func PgByteaToBase64StringPtr(b []byte) *string {
+	if b == nil {
+		return nil
+	}
+	s := base64.StdEncoding.EncodeToString(b)
+	return &s
+}
+
+// Some legacy columns keep UTF-8 text in bytea. PgByteaToStringPtr replaces
+// invalid sequences with U+FFFD; PgByteaToValidStringPtr rejects them.
+
+var errInvalidUTF8 = errors.New("sqlcmapper: bytea is not valid UTF-8")
+
+func PgByteaToStringPtr(b []byte) *string {
+	if b == nil {
+		return nil
+	}
+	s := strings.ToValidUTF8(string(b), "\uFFFD")
+	return &s
+}
+
+func PgByteaToValidStringPtr(b []byte) (*string, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if !utf8.Valid(b) {
+		return nil, errInvalidUTF8
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// BinaryEncoding selects how the auto-mapper renders bytea columns into
+// string and *string model fields.
+type BinaryEncoding int
+
+const (
+	HexEncoding BinaryEncoding = iota
+	Base64Encoding
+)
+
+func (e BinaryEncoding) encodePtr(b []byte) *string {
+	if e == Base64Encoding {
+		return PgByteaToBase64StringPtr(b)
+	}
+	return PgByteaToHexStringPtr(b)
+}
+
+var byteSliceType = reflect.TypeOf([]byte(nil))
+
+// setByteaStringField encodes a []byte db value into a string or *string
+// model field; NULL gives "" or nil.
+func setByteaStringField(field reflect.Value, b []byte, cfg *config) bool {
+	s := cfg.binaryEncoding.encodePtr(b)
+	switch {
+	case field.Kind() == reflect.String:
+		if s == nil {
+			field.SetString("")
+		} else {
+			field.SetString(*s)
+		}
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(s))
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package sqlcmapper
+
+import "testing"
+
+func TestPgByteaEncodings(t *testing.T) {
+	b := []byte{0xde, 0xad, 0xbe, 0xef}
+	if got := PgByteaToHexString(b); got != "deadbeef" {
+		t.Errorf("hex = %q", got)
+	}
+	if got := PgByteaToBase64String(b); got != "3q2+7w==" {
+		t.Errorf("base64 = %q", got)
+	}
+	if PgByteaToHexString(nil) != "" || PgByteaToBase64String(nil) != "" {
+		t.Error("NULL should encode as \"\"")
+	}
+	if PgByteaToHexStringPtr(nil) != nil || PgByteaToBase64StringPtr(nil) != nil {
+		t.Error("NULL should encode as nil")
+	}
+	if p := PgByteaToHexStringPtr([]byte{}); p == nil || *p != "" {
+		t.Error("an empty non-NULL bytea should encode as a pointer to \"\"")
+	}
+}
+
+type byteaRow struct {
+	Blob []byte
+	Opt  []byte
+}
+
+type byteaModel struct {
+	Blob string
+	Opt  *string
+}
+
+func TestAutoMapByteaEncoding(t *testing.T) {
+	row := byteaRow{Blob: []byte{0xff, 0x01}, Opt: []byte{0xff, 0x01}}
+	got, err := AutoMapWithTags[byteaRow, byteaModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Blob != "ff01" || got.Opt == nil || *got.Opt != "ff01" {
+		t.Fatalf("hex default: got %q, %v", got.Blob, got.Opt)
+	}
+	got, err = AutoMapWithTags[byteaRow, byteaModel](row, WithBinaryEncoding(Base64Encoding))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Blob != "/wE=" || *got.Opt != "/wE=" {
+		t.Fatalf("base64: got %q, %q", got.Blob, *got.Opt)
+	}
+}
+
+func TestAutoMapIntoNullByteaClearsString(t *testing.T) {
+	keep := "keep"
+	dst := byteaModel{Blob: "keep", Opt: &keep}
+	if err := AutoMapInto(byteaRow{}, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Blob != "" || dst.Opt != nil {
+		t.Fatalf("got %q, %v; want \"\" and nil for NULL", dst.Blob, dst.Opt)
+	}
+}
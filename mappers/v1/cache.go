@@ -0,0 +1,91 @@
+package sqlcmapper
+
+import (
+	"container/list"
+	"sync"
+)
+
+/////////////////////
+// CachingMapper
+/////////////////////
+
+// CachingMapper memoizes a mapping function by its input, for reference data
+// where the same values recur (status_id -> StatusDTO). With a positive
+// limit it keeps only the most recently used entries; otherwise the cache is
+// unbounded. It is safe for concurrent use and satisfies Mapper.
+type CachingMapper[From comparable, To any] struct {
+	fn    func(From) To
+	limit int
+
+	mu      sync.Mutex
+	entries map[From]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+type cacheEntry[From comparable, To any] struct {
+	key   From
+	value To
+}
+
+func NewCachingMapper[From comparable, To any](fn func(From) To, limit int) *CachingMapper[From, To] {
+	return &CachingMapper[From, To]{
+		fn:      fn,
+		limit:   limit,
+		entries: make(map[From]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Map returns the cached result for f, calling the wrapped function on a
+// miss. The function runs outside the lock, so concurrent misses on the same
+// key may both call it.
+func (m *CachingMapper[From, To]) Map(f From) To {
+	m.mu.Lock()
+	if el, ok := m.entries[f]; ok {
+		m.lru.MoveToFront(el)
+		v := el.Value.(*cacheEntry[From, To]).value
+		m.mu.Unlock()
+		return v
+	}
+	m.mu.Unlock()
+
+	v := m.fn(f)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[f]; ok {
+		el.Value.(*cacheEntry[From, To]).value = v
+		m.lru.MoveToFront(el)
+		return v
+	}
+	m.entries[f] = m.lru.PushFront(&cacheEntry[From, To]{key: f, value: v})
+	if m.limit > 0 && m.lru.Len() > m.limit {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*cacheEntry[From, To]).key)
+	}
+	return v
+}
+
+func (m *CachingMapper[From, To]) MapSlice(fs []From) []To {
+	out := make([]To, len(fs))
+	for i, f := range fs {
+		out[i] = m.Map(f)
+	}
+	return out
+}
+
+// Len reports the number of cached entries.
+func (m *CachingMapper[From, To]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Clear drops every cached entry.
+func (m *CachingMapper[From, To]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[From]*list.Element)
+	m.lru.Init()
+}
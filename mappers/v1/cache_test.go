@@ -0,0 +1,89 @@
+package sqlcmapper
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// countingMapper wraps strconv.Itoa and counts how often each key misses.
+func countingMapper(limit int) (*CachingMapper[int, string], map[int]int) {
+	calls := make(map[int]int)
+	var mu sync.Mutex
+	return NewCachingMapper(func(i int) string {
+		mu.Lock()
+		calls[i]++
+		mu.Unlock()
+		return strconv.Itoa(i)
+	}, limit), calls
+}
+
+func TestCachingMapperEvictsLeastRecentlyUsed(t *testing.T) {
+	m, calls := countingMapper(2)
+	m.Map(1)
+	m.Map(2)
+	m.Map(1) // 1 is now the most recently used
+	m.Map(3) // evicts 2
+	if n := m.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+	if got := m.Map(1); got != "1" || calls[1] != 1 {
+		t.Fatalf("key 1: got %q after %d calls, want a cached hit", got, calls[1])
+	}
+	m.Map(2)
+	if calls[2] != 2 {
+		t.Fatalf("key 2 was called %d times, want it evicted and recomputed", calls[2])
+	}
+}
+
+func TestCachingMapperBounds(t *testing.T) {
+	bounded, _ := countingMapper(3)
+	unbounded, calls := countingMapper(0)
+	for i := 0; i < 10; i++ {
+		bounded.Map(i)
+		unbounded.Map(i)
+	}
+	if n := bounded.Len(); n != 3 {
+		t.Fatalf("bounded Len = %d, want 3", n)
+	}
+	if n := unbounded.Len(); n != 10 {
+		t.Fatalf("unbounded Len = %d, want 10", n)
+	}
+	if got := unbounded.MapSlice([]int{0, 9}); got[0] != "0" || got[1] != "9" || calls[0] != 1 || calls[9] != 1 {
+		t.Fatalf("MapSlice = %v with calls %v, want cached hits", got, calls)
+	}
+}
+
+func TestCachingMapperClear(t *testing.T) {
+	m, calls := countingMapper(0)
+	m.Map(1)
+	m.Clear()
+	if n := m.Len(); n != 0 {
+		t.Fatalf("Len after Clear = %d, want 0", n)
+	}
+	m.Map(1)
+	if calls[1] != 2 {
+		t.Fatalf("key 1 was called %d times, want a miss after Clear", calls[1])
+	}
+}
+
+func TestCachingMapperConcurrent(t *testing.T) {
+	m, _ := countingMapper(8)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if got, want := m.Map((g+i)%16), strconv.Itoa((g+i)%16); got != want {
+					t.Errorf("Map = %q, want %q", got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := m.Len(); n > 8 {
+		t.Fatalf("Len = %d, want at most 8", n)
+	}
+}
@@ -0,0 +1,143 @@
+package sqlcmapper
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+/////////////////////
+// Custom converters
+/////////////////////
+
+// Converters teach the auto-mapper how to turn a db field type into a model
+// field type. They are consulted before the built-in pgtype handling.
+//
+// When no converter goes straight from the db type to the model type, the
+// mapper chains converters whose types line up, e.g. pgtype.Numeric ->
+// decimal.Decimal -> string. The shortest chain wins; among chains of equal
+// length, converters passed via WithConverter are tried before globally
+// registered ones, and each group is tried in the order it was added.
+//
+// Context converters receive the context passed to AutoMapWithTagsCtx (or
+// context.Background() for the other entry points), which lets a conversion
+// depend on request scope such as the caller's locale or tenant. Both kinds
+// take part in the same chains.
+
+type converter struct {
+	from reflect.Type
+	to   reflect.Type
+	fn   func(context.Context, any) (any, error)
+}
+
+var (
+	convertersMu     sync.RWMutex
+	globalConverters []converter
+)
+
+func newConverter[From any, To any](fn func(From) (To, error)) converter {
+	return converter{
+		from: reflect.TypeOf((*From)(nil)).Elem(),
+		to:   reflect.TypeOf((*To)(nil)).Elem(),
+		fn: func(_ context.Context, v any) (any, error) {
+			return fn(v.(From))
+		},
+	}
+}
+
+func newContextConverter[From any, To any](fn func(context.Context, From) (To, error)) converter {
+	return converter{
+		from: reflect.TypeOf((*From)(nil)).Elem(),
+		to:   reflect.TypeOf((*To)(nil)).Elem(),
+		fn: func(ctx context.Context, v any) (any, error) {
+			return fn(ctx, v.(From))
+		},
+	}
+}
+
+// RegisterConverter adds a converter used by every auto-map call.
+func RegisterConverter[From any, To any](fn func(From) (To, error)) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	globalConverters = append(globalConverters, newConverter(fn))
+	registrations.Add(1)
+}
+
+// WithConverter adds a converter for a single auto-map call.
+func WithConverter[From any, To any](fn func(From) (To, error)) Option {
+	return func(c *config) {
+		c.converters = append(c.converters, newConverter(fn))
+	}
+}
+
+// RegisterContextConverter adds a context-aware converter used by every
+// auto-map call.
+func RegisterContextConverter[From any, To any](fn func(context.Context, From) (To, error)) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	globalConverters = append(globalConverters, newContextConverter(fn))
+	registrations.Add(1)
+}
+
+// WithContextConverter adds a context-aware converter for a single auto-map
+// call.
+func WithContextConverter[From any, To any](fn func(context.Context, From) (To, error)) Option {
+	return func(c *config) {
+		c.converters = append(c.converters, newContextConverter(fn))
+	}
+}
+
+// findConverterChain returns the shortest sequence of converters turning from
+// into to, or nil when there is none.
+func (c *config) findConverterChain(from, to reflect.Type) []converter {
+	convertersMu.RLock()
+	all := make([]converter, 0, len(c.converters)+len(globalConverters))
+	all = append(all, c.converters...)
+	all = append(all, globalConverters...)
+	convertersMu.RUnlock()
+	if len(all) == 0 {
+		return nil
+	}
+
+	type step struct {
+		typ   reflect.Type
+		chain []converter
+	}
+	seen := map[reflect.Type]bool{from: true}
+	queue := []step{{typ: from}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, conv := range all {
+			if conv.from != cur.typ {
+				continue
+			}
+			chain := append(append([]converter(nil), cur.chain...), conv)
+			if conv.to == to {
+				return chain
+			}
+			if seen[conv.to] {
+				continue
+			}
+			seen[conv.to] = true
+			queue = append(queue, step{typ: conv.to, chain: chain})
+		}
+	}
+	return nil
+}
+
+func applyConverterChain(ctx context.Context, chain []converter, v reflect.Value) (reflect.Value, error) {
+	cur := v.Interface()
+	for _, conv := range chain {
+		out, err := conv.fn(ctx, cur)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		cur = out
+	}
+	last := chain[len(chain)-1].to
+	if cur == nil {
+		return reflect.Zero(last), nil
+	}
+	return reflect.ValueOf(cur), nil
+}
@@ -0,0 +1,139 @@
+package sqlcmapper
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+// Distinct types keep these converters from matching fields in other tests.
+type (
+	chainCents int64
+	chainMoney struct{ Units, Cents int64 }
+	chainTag   string
+	chainMid   string
+	chainAlt   string
+	chainCode  string
+)
+
+type chainRow struct {
+	Price chainCents
+}
+
+type chainModel struct {
+	Price string
+}
+
+func centsToMoney(c chainCents) (chainMoney, error) {
+	return chainMoney{Units: int64(c) / 100, Cents: int64(c) % 100}, nil
+}
+
+func moneyToString(m chainMoney) (string, error) {
+	return strconv.FormatInt(m.Units, 10) + "." + strconv.FormatInt(m.Cents, 10), nil
+}
+
+func TestConverterChainTwoSteps(t *testing.T) {
+	got, err := AutoMapWithTags[chainRow, chainModel](chainRow{Price: 1250},
+		WithConverter(moneyToString), WithConverter(centsToMoney))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Price != "12.50" {
+		t.Fatalf("Price = %q, want %q", got.Price, "12.50")
+	}
+	plan, err := ExplainMapping[chainRow, chainModel](WithConverter(moneyToString), WithConverter(centsToMoney))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := plan.Fields[0].Kind; kind != "converter" {
+		t.Fatalf("Kind = %q, want %q", kind, "converter")
+	}
+}
+
+func TestConverterChainPrefersShortest(t *testing.T) {
+	direct := func(c chainCents) (string, error) { return "direct", nil }
+	got, err := AutoMapWithTags[chainRow, chainModel](chainRow{Price: 1},
+		WithConverter(centsToMoney), WithConverter(moneyToString), WithConverter(direct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Price != "direct" {
+		t.Fatalf("Price = %q, want the single-step converter", got.Price)
+	}
+}
+
+type chainTieRow struct {
+	Label chainTag
+	Code  chainCode
+}
+
+type chainTieModel struct {
+	Label string
+	Code  string
+}
+
+func TestConverterChainPerCallWinsTie(t *testing.T) {
+	defer restoreGlobalConverters(snapshotGlobalConverters())
+	RegisterConverter(func(chainTag) (string, error) { return "global", nil })
+	RegisterConverter(func(v chainCode) (chainAlt, error) { return chainAlt(v), nil })
+	RegisterConverter(func(v chainAlt) (string, error) { return "via alt " + string(v), nil })
+	RegisterConverter(func(v chainMid) (string, error) { return "via mid " + string(v), nil })
+
+	// Label ties between one-step converters; Code between two-step chains,
+	// where only the first step of the chain through chainMid is per-call.
+	got, err := AutoMapWithTags[chainTieRow, chainTieModel](chainTieRow{Label: "x", Code: "y"},
+		WithConverter(func(chainTag) (string, error) { return "per-call", nil }),
+		WithConverter(func(v chainCode) (chainMid, error) { return chainMid(v), nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (chainTieModel{Label: "per-call", Code: "via mid y"}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func snapshotGlobalConverters() []converter {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	return append([]converter(nil), globalConverters...)
+}
+
+func restoreGlobalConverters(convs []converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	globalConverters = convs
+	registrations.Add(1)
+}
+
+type localeKey struct{}
+
+type (
+	statusCode  string
+	localeInner struct{ Status statusCode }
+	localeRow   struct{ Inner localeInner }
+	localeOut   struct{ Status string }
+	localeModel struct{ Inner localeOut }
+)
+
+func TestContextConverterInNestedStruct(t *testing.T) {
+	ctx := context.WithValue(context.Background(), localeKey{}, "nb")
+	conv := WithContextConverter(func(ctx context.Context, s statusCode) (string, error) {
+		locale, _ := ctx.Value(localeKey{}).(string)
+		return locale + ":" + string(s), nil
+	})
+	got, err := AutoMapWithTagsCtx[localeRow, localeModel](ctx, localeRow{Inner: localeInner{Status: "open"}}, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Inner.Status != "nb:open" {
+		t.Fatalf("Status = %q, want the nested converter to see ctx", got.Inner.Status)
+	}
+
+	got, err = AutoMapWithTags[localeRow, localeModel](localeRow{Inner: localeInner{Status: "open"}}, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Inner.Status != ":open" {
+		t.Fatalf("without ctx: Status = %q, want context.Background()", got.Inner.Status)
+	}
+}
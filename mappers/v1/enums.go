@@ -0,0 +1,48 @@
+package sqlcmapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/////////////////////
+// String enums
+/////////////////////
+
+var stringEnums sync.Map // reflect.Type -> map[string]bool
+
+// RegisterStringEnum declares the allowed values of a string enum type. Under
+// WithStrict, mapping a text column into T or *T then fails for any other
+// value; without it the value is copied as is. NULL is always allowed.
+// Registering the same type again replaces its set.
+func RegisterStringEnum[T ~string](allowed ...T) {
+	set := make(map[string]bool, len(allowed))
+	for _, v := range allowed {
+		set[string(v)] = true
+	}
+	stringEnums.Store(reflect.TypeOf((*T)(nil)).Elem(), set)
+	registrations.Add(1)
+}
+
+// enumCheck returns a validator for fieldType (or its element, for a
+// pointer) when it is a registered enum and cfg is strict, else nil.
+func enumCheck(fieldType reflect.Type, cfg *config) func(string) error {
+	if !cfg.strict {
+		return nil
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	set, ok := stringEnums.Load(fieldType)
+	if !ok {
+		return nil
+	}
+	allowed := set.(map[string]bool)
+	return func(s string) error {
+		if !allowed[s] {
+			return fmt.Errorf("%q is not a valid %s", s, fieldType)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type orderStatus string
+
+const (
+	statusOpen   orderStatus = "open"
+	statusClosed orderStatus = "closed"
+)
+
+type enumRow struct {
+	Status pgtype.Text
+	Prev   pgtype.Text
+}
+
+type enumModel struct {
+	Status orderStatus
+	Prev   *orderStatus
+}
+
+func TestRegisterStringEnum(t *testing.T) {
+	RegisterStringEnum(statusOpen, statusClosed)
+
+	row := enumRow{Status: pgtype.Text{String: "open", Valid: true}}
+	got, err := AutoMapWithTags[enumRow, enumModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != statusOpen || got.Prev != nil {
+		t.Fatalf("got Status %q, Prev %v; want open and NULL", got.Status, show(got.Prev))
+	}
+
+	row = enumRow{Status: pgtype.Text{String: "open", Valid: true}, Prev: pgtype.Text{String: "lost", Valid: true}}
+	_, err = AutoMapWithTags[enumRow, enumModel](row, WithStrict())
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) || mapErr.Field != "Prev" || !strings.Contains(err.Error(), `"lost" is not a valid sqlcmapper.orderStatus`) {
+		t.Fatalf("strict: err = %v, want a MapError for Prev", err)
+	}
+
+	got, err = AutoMapWithTags[enumRow, enumModel](row)
+	if err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+	if got.Prev == nil || *got.Prev != "lost" {
+		t.Fatalf("lenient: Prev = %v, want the value copied as is", show(got.Prev))
+	}
+}
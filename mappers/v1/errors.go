@@ -0,0 +1,99 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/////////////////////
+// Errors
+/////////////////////
+
+// MapError describes why a model field could not be mapped.
+type MapError struct {
+	Field  string // model field name
+	Column string // db column the field was matched against
+	Reason string
+	Err    error
+}
+
+func (e *MapError) Error() string {
+	msg := fmt.Sprintf("sqlcmapper: field %s (column %q): %s", e.Field, e.Column, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *MapError) Unwrap() error {
+	return e.Err
+}
+
+// MapErrors aggregates every MapError from one mapping call when
+// WithCollectErrors is set. It supports errors.Is and errors.As through
+// Unwrap.
+type MapErrors struct {
+	Errors []*MapError
+}
+
+func (e *MapErrors) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("sqlcmapper: %d mapping errors: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *MapErrors) Unwrap() []error {
+	out := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		out[i] = err
+	}
+	return out
+}
+
+// SliceElementError reports the first mapped slice element that failed
+// validation (see GenericMapperE.WithValidation).
+type SliceElementError struct {
+	Index int
+	Cause error
+}
+
+func (e *SliceElementError) Error() string {
+	return fmt.Sprintf("sqlcmapper: element %d failed validation: %v", e.Index, e.Cause)
+}
+
+func (e *SliceElementError) Unwrap() error {
+	return e.Cause
+}
+
+// appendMapErrors flattens err, which may itself be a MapErrors from a nested
+// struct, into dst.
+func appendMapErrors(dst []*MapError, err error) []*MapError {
+	var multi *MapErrors
+	if errors.As(err, &multi) {
+		return append(dst, multi.Errors...)
+	}
+	var one *MapError
+	if errors.As(err, &one) {
+		return append(dst, one)
+	}
+	return append(dst, &MapError{Reason: "mapping failed", Err: err})
+}
+
+// prefixMapErrors qualifies the fields of a nested struct's errors with the
+// parent field name, giving paths like "Address.City".
+func prefixMapErrors(err error, parent string) error {
+	errs := appendMapErrors(nil, err)
+	out := make([]*MapError, len(errs))
+	for i, e := range errs {
+		cp := *e
+		cp.Field = parent + "." + e.Field
+		out[i] = &cp
+	}
+	if len(out) == 1 {
+		return out[0]
+	}
+	return &MapErrors{Errors: out}
+}
@@ -0,0 +1,72 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errBadCode = errors.New("bad code")
+
+type collectRow struct {
+	Code  pgtype.Text
+	Count pgtype.Int4
+}
+
+type collectModel struct {
+	Code    collectCode
+	Count   []int
+	Missing string
+}
+
+type collectCode string
+
+func TestWithCollectErrors(t *testing.T) {
+	conv := WithConverter(func(pgtype.Text) (collectCode, error) { return "", errBadCode })
+
+	_, err := AutoMapWithTags[collectRow, collectModel](collectRow{}, WithStrict(), conv)
+	var multi *MapErrors
+	if errors.As(err, &multi) {
+		t.Fatalf("without WithCollectErrors: got %d errors, want the first only", len(multi.Errors))
+	}
+
+	_, err = AutoMapWithTags[collectRow, collectModel](collectRow{}, WithStrict(), WithCollectErrors(), conv)
+	if !errors.As(err, &multi) {
+		t.Fatalf("err = %v, want *MapErrors", err)
+	}
+	var fields []string
+	for _, e := range multi.Errors {
+		fields = append(fields, e.Field)
+	}
+	if want := []string{"Code", "Count", "Missing"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("fields = %v, want %v", fields, want)
+	}
+	if got := len(multi.Unwrap()); got != 3 {
+		t.Fatalf("Unwrap returned %d errors, want 3", got)
+	}
+	if !errors.Is(err, errBadCode) {
+		t.Fatal("errors.Is did not find the converter's error")
+	}
+	var first *MapError
+	if !errors.As(err, &first) || first.Field != "Code" {
+		t.Fatalf("errors.As found %v, want the Code error", first)
+	}
+}
+
+type collectNestedRow struct {
+	Inner collectRow
+}
+
+type collectNestedModel struct {
+	Inner collectModel
+}
+
+func TestWithCollectErrorsFlattensNested(t *testing.T) {
+	_, err := AutoMapWithTags[collectNestedRow, collectNestedModel](collectNestedRow{}, WithStrict(), WithCollectErrors(), WithErrorOnPartialNested())
+	var multi *MapErrors
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 || multi.Errors[0].Field != "Inner.Count" || multi.Errors[1].Field != "Inner.Missing" {
+		t.Fatalf("err = %v, want two flattened errors under Inner", err)
+	}
+}
@@ -0,0 +1,69 @@
+package sqlcmapper
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+/////////////////////
+// db field lookup cache
+/////////////////////
+
+// dbFieldIndexes caches, per db struct type, the index of every field under
+// both its Go name and its snake_case name, so matching a model field is a
+// map lookup instead of a FieldByNameFunc scan.
+var dbFieldIndexes sync.Map // reflect.Type -> map[string][]int
+
+func dbFieldIndex(t reflect.Type) map[string][]int {
+	if cached, ok := dbFieldIndexes.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	index := make(map[string][]int)
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() {
+			continue
+		}
+		for _, name := range []string{sf.Name, toSnakeCase(sf.Name)} {
+			// Like FieldByName, shallower fields shadow promoted ones.
+			if prev, ok := index[name]; ok && len(prev) <= len(sf.Index) {
+				continue
+			}
+			index[name] = sf.Index
+		}
+	}
+	cached, _ := dbFieldIndexes.LoadOrStore(t, index)
+	return cached.(map[string][]int)
+}
+
+// matchDBField finds the db field for a model column name, applying the
+// config's name matching options.
+func (c *config) matchDBField(index map[string][]int, column string) ([]int, bool) {
+	if idx, ok := index[column]; ok {
+		return idx, true
+	}
+	if c.snakeCaseBoth {
+		if idx, ok := index[toSnakeCase(column)]; ok {
+			return idx, true
+		}
+	}
+	if c.nameNormalizer != nil {
+		return matchNormalized(index, c.nameNormalizer(column), c.nameNormalizer)
+	}
+	return nil, false
+}
+
+// matchNormalized finds the db field whose normalized name equals want,
+// preferring shallower fields and then declaration order.
+func matchNormalized(index map[string][]int, want string, normalize func(string) string) ([]int, bool) {
+	var best []int
+	for name, idx := range index {
+		if normalize(name) != want {
+			continue
+		}
+		if best == nil || len(idx) < len(best) || (len(idx) == len(best) && slices.Compare(idx, best) < 0) {
+			best = idx
+		}
+	}
+	return best, best != nil
+}
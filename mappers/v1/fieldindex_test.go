@@ -0,0 +1,185 @@
+package sqlcmapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type benchRow struct {
+	ID        int64
+	UserID    int64
+	OrgID     int64
+	Name      pgtype.Text
+	Email     pgtype.Text
+	Phone     pgtype.Text
+	Street    string
+	City      string
+	Zip       string
+	Country   string
+	Age       pgtype.Int4
+	Score     pgtype.Float8
+	Active    pgtype.Bool
+	Verified  bool
+	Role      string
+	Plan      string
+	Seats     int32
+	Balance   int64
+	Notes     pgtype.Text
+	CreatedBy string
+}
+
+type benchModel struct {
+	ID        int64
+	UserID    int64
+	OrgID     int64
+	Name      string
+	Email     *string
+	Phone     string
+	Street    string
+	City      string
+	Zip       string
+	Country   string
+	Age       int32
+	Score     float64
+	Active    bool
+	Verified  bool
+	Role      string
+	Plan      string
+	Seats     int64
+	Balance   int64
+	Notes     *string
+	CreatedBy string
+}
+
+func benchRows(n int) []benchRow {
+	rows := make([]benchRow, n)
+	for i := range rows {
+		rows[i] = benchRow{
+			ID:     int64(i),
+			Name:   pgtype.Text{String: "name", Valid: true},
+			Email:  pgtype.Text{String: "a@b.c", Valid: true},
+			Age:    pgtype.Int4{Int32: 30, Valid: true},
+			Score:  pgtype.Float8{Float64: 1.5, Valid: true},
+			Active: pgtype.Bool{Bool: true, Valid: true},
+			City:   "city",
+			Seats:  3,
+		}
+	}
+	return rows
+}
+
+// TestBenchModelFullyMapped keeps the benchmarks honest: every one of the 20
+// fields must have a conversion, or the benchmarks measure fewer fields than
+// they claim to.
+func TestBenchModelFullyMapped(t *testing.T) {
+	got, err := AutoMapWithTags[benchRow, benchModel](benchRows(1)[0], WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Score != 1.5 || !got.Active || got.Age != 30 || got.Email == nil || got.Seats != 3 {
+		t.Fatalf("got %+v", got)
+	}
+	plan, err := ExplainMapping[benchRow, benchModel]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range plan.Fields {
+		if f.Kind == "unmatched" || f.Kind == "unsupported" {
+			t.Errorf("%s is %s", f.Field, f.Kind)
+		}
+	}
+}
+
+// BenchmarkAutoMapWithTags maps a 20-field row per call, the row-by-row use
+// that depends on plans being shared across calls.
+func BenchmarkAutoMapWithTags(b *testing.B) {
+	row := benchRows(1)[0]
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := AutoMapWithTags[benchRow, benchModel](row); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAutoMapSliceWithTags100k maps 100k 20-field rows per op.
+func BenchmarkAutoMapSliceWithTags100k(b *testing.B) {
+	rows := benchRows(100_000)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := AutoMapSliceWithTags[benchRow, benchModel](rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFieldLookup compares resolving every model field of a 20-field
+// row with a FieldByNameFunc scan, as the mapper used to, against the cached
+// name index, 100k rows per op.
+func BenchmarkFieldLookup(b *testing.B) {
+	row := reflect.ValueOf(benchRows(1)[0])
+	modelType := reflect.TypeOf(benchModel{})
+	const rows = 100_000
+
+	b.Run("FieldByNameFunc", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for range rows {
+				for i := 0; i < modelType.NumField(); i++ {
+					name := modelType.Field(i).Name
+					f := row.FieldByNameFunc(func(s string) bool {
+						return s == name || toSnakeCase(s) == name
+					})
+					if !f.IsValid() {
+						b.Fatal(name)
+					}
+				}
+			}
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for range rows {
+				index := dbFieldIndex(row.Type())
+				for i := 0; i < modelType.NumField(); i++ {
+					if _, ok := index[modelType.Field(i).Name]; !ok {
+						b.Fatal(modelType.Field(i).Name)
+					}
+				}
+			}
+		}
+	})
+}
+
+type indexInner struct {
+	Name string
+	Deep string
+}
+
+type indexRow struct {
+	indexInner
+	Name   string
+	UserID int64
+	hidden string
+}
+
+func TestDBFieldIndex(t *testing.T) {
+	index := dbFieldIndex(reflect.TypeOf(indexRow{}))
+	for name, want := range map[string][]int{
+		"Name":    {1},
+		"UserID":  {2},
+		"user_id": {2},
+		"Deep":    {0, 1},
+		"deep":    {0, 1},
+	} {
+		if got := index[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("index[%q] = %v, want %v", name, got, want)
+		}
+	}
+	if _, ok := index["hidden"]; ok {
+		t.Error("unexported fields should not be indexed")
+	}
+}
@@ -0,0 +1,114 @@
+package sqlcmapper
+
+import (
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Geometry helpers
+/////////////////////
+
+// Point is a plain x/y pair. Any struct with the same two fields can be used
+// as an auto-mapper target for point columns, or as the element of a slice
+// target for polygon and path columns.
+type Point struct {
+	X, Y float64
+}
+
+func PgPointToPointPtr(p pgtype.Point) *Point {
+	if !p.Valid {
+		return nil
+	}
+	return &Point{X: p.P.X, Y: p.P.Y}
+}
+
+// PgPolygonToPoints returns the polygon's vertices, or nil when NULL.
+func PgPolygonToPoints(p pgtype.Polygon) []Point {
+	if !p.Valid {
+		return nil
+	}
+	return vec2sToPoints(p.P)
+}
+
+// PgPathToPoints returns the path's vertices and whether it is closed, or nil
+// when NULL.
+func PgPathToPoints(p pgtype.Path) (points []Point, closed bool) {
+	if !p.Valid {
+		return nil, false
+	}
+	return vec2sToPoints(p.P), p.Closed
+}
+
+func vec2sToPoints(vs []pgtype.Vec2) []Point {
+	out := make([]Point, len(vs))
+	for i, v := range vs {
+		out[i] = Point{X: v.X, Y: v.Y}
+	}
+	return out
+}
+
+var (
+	pointType   = reflect.TypeOf(Point{})
+	pgPointType = reflect.TypeOf(pgtype.Point{})
+)
+
+// isPointTarget reports whether t is a Point-shaped struct.
+func isPointTarget(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && pointType.ConvertibleTo(t)
+}
+
+func isPgVertexType(t reflect.Type) bool {
+	_, _, ok := pgVertices(reflect.Zero(t))
+	return ok
+}
+
+// pgVertices returns the vertex list of a polygon or path db value, with
+// ok false for other types.
+func pgVertices(v reflect.Value) (vs []pgtype.Vec2, valid, ok bool) {
+	switch g := v.Interface().(type) {
+	case pgtype.Polygon:
+		return g.P, g.Valid, true
+	case pgtype.Path:
+		return g.P, g.Valid, true
+	}
+	return nil, false, false
+}
+
+// setPointsField fills a slice of Point-shaped structs from a polygon or path
+// db value; NULL leaves the slice nil.
+func setPointsField(field, dbField reflect.Value) {
+	vs, valid, _ := pgVertices(dbField)
+	if !valid {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	elem := field.Type().Elem()
+	out := reflect.MakeSlice(field.Type(), len(vs), len(vs))
+	for i, v := range vs {
+		out.Index(i).Set(reflect.ValueOf(Point{X: v.X, Y: v.Y}).Convert(elem))
+	}
+	field.Set(out)
+}
+
+// setPointField stores a pgtype.Point into a Point-shaped struct or a pointer
+// to one; NULL gives the zero value or nil.
+func setPointField(field, dbField reflect.Value) {
+	p := PgPointToPointPtr(dbField.Interface().(pgtype.Point))
+	if field.Kind() == reflect.Ptr {
+		if p == nil {
+			field.Set(reflect.Zero(field.Type()))
+			return
+		}
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(reflect.ValueOf(*p).Convert(field.Type().Elem()))
+		field.Set(ptr)
+		return
+	}
+	if p == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	field.Set(reflect.ValueOf(*p).Convert(field.Type()))
+}
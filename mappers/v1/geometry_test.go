@@ -0,0 +1,68 @@
+package sqlcmapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var triangle = []pgtype.Vec2{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 3}}
+
+func TestPgPolygonToPoints(t *testing.T) {
+	got := PgPolygonToPoints(pgtype.Polygon{P: triangle, Valid: true})
+	want := []Point{{0, 0}, {4, 0}, {0, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if PgPolygonToPoints(pgtype.Polygon{}) != nil {
+		t.Fatal("NULL should give nil")
+	}
+}
+
+func TestPgPathToPoints(t *testing.T) {
+	got, closed := PgPathToPoints(pgtype.Path{P: triangle, Closed: true, Valid: true})
+	if len(got) != 3 || got[1] != (Point{4, 0}) || !closed {
+		t.Fatalf("got %v, closed=%v", got, closed)
+	}
+	if got, closed := PgPathToPoints(pgtype.Path{P: triangle[:2], Valid: true}); len(got) != 2 || closed {
+		t.Fatalf("open path: got %v, closed=%v", got, closed)
+	}
+	if got, closed := PgPathToPoints(pgtype.Path{}); got != nil || closed {
+		t.Fatal("NULL should give nil, false")
+	}
+}
+
+type vertex struct{ X, Y float64 }
+
+type shapeRow struct {
+	Area  pgtype.Polygon
+	Route pgtype.Path
+	Pin   pgtype.Point
+}
+
+type shapeModel struct {
+	Area  []vertex
+	Route []Point
+	Pin   *vertex
+}
+
+func TestAutoMapGeometry(t *testing.T) {
+	row := shapeRow{
+		Area:  pgtype.Polygon{P: triangle, Valid: true},
+		Route: pgtype.Path{P: triangle, Valid: true},
+		Pin:   pgtype.Point{P: pgtype.Vec2{X: 1, Y: 2}, Valid: true},
+	}
+	got, err := AutoMapWithTags[shapeRow, shapeModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Area, []vertex{{0, 0}, {4, 0}, {0, 3}}) || len(got.Route) != 3 || got.Pin == nil || *got.Pin != (vertex{1, 2}) {
+		t.Fatalf("got %+v", got)
+	}
+
+	got, err = AutoMapWithTags[shapeRow, shapeModel](shapeRow{})
+	if err != nil || got.Area != nil || got.Route != nil || got.Pin != nil {
+		t.Fatalf("NULL: got %+v, %v", got, err)
+	}
+}
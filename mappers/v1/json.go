@@ -0,0 +1,82 @@
+package sqlcmapper
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+/////////////////////
+// JSON helpers
+/////////////////////
+
+// pgx v5 scans jsonb into []byte (sqlc may also emit json.RawMessage), with
+// nil standing for NULL.
+
+func PgJSONBToRawMessage(b []byte) json.RawMessage {
+	if b == nil {
+		return nil
+	}
+	return json.RawMessage(b)
+}
+
+// PgJSONBToStruct decodes a jsonb value into a new T. NULL yields nil.
+func PgJSONBToStruct[T any](b []byte) (*T, error) {
+	if b == nil {
+		return nil, nil
+	}
+	out := new(T)
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// json columns have the same Go representation as jsonb in pgx v5 (there is
+// no pgtype.JSON), so these mirror the jsonb helpers and the auto-mapper
+// treats both column types identically.
+
+func PgJSONToRawMessage(b []byte) json.RawMessage {
+	return PgJSONBToRawMessage(b)
+}
+
+func PgJSONToStruct[T any](b []byte) (*T, error) {
+	return PgJSONBToStruct[T](b)
+}
+
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isJSONTarget reports whether a model field of type t should be decoded from
+// a json/jsonb column: maps, non-byte slices and structs, named or not, and
+// pointers to them.
+func isJSONTarget(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Struct:
+		return t != timeType
+	}
+	return false
+}
+
+// setJSONField unmarshals b into a fresh value of field's own (possibly named)
+// type, so nothing the field held before, such as old map keys, survives. An
+// empty or NULL value leaves the field zero.
+func setJSONField(field reflect.Value, b []byte) error {
+	if len(b) == 0 {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	out := reflect.New(field.Type())
+	if err := json.Unmarshal(b, out.Interface()); err != nil {
+		return err
+	}
+	field.Set(out.Elem())
+	return nil
+}
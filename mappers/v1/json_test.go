@@ -0,0 +1,52 @@
+package sqlcmapper
+
+import (
+	"reflect"
+	"testing"
+)
+
+type jsonProps map[string]string
+
+type jsonTags []string
+
+type jsonRow struct {
+	Props []byte
+	Tags  []byte
+}
+
+type jsonModel struct {
+	Props jsonProps
+	Tags  jsonTags
+}
+
+func TestAutoMapJSONIntoNamedMapAndSlice(t *testing.T) {
+	row := jsonRow{Props: []byte(`{"k":"v"}`), Tags: []byte(`["a","b"]`)}
+	got, err := AutoMapWithTags[jsonRow, jsonModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := jsonModel{Props: jsonProps{"k": "v"}, Tags: jsonTags{"a", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAutoMapJSONNull(t *testing.T) {
+	got, err := AutoMapWithTags[jsonRow, jsonModel](jsonRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Props != nil || got.Tags != nil {
+		t.Fatalf("got %+v, want zero fields", got)
+	}
+}
+
+func TestAutoMapIntoJSONReplacesMap(t *testing.T) {
+	dst := jsonModel{Props: jsonProps{"old": "1"}}
+	if err := AutoMapInto(jsonRow{Props: []byte(`{"new":"2"}`)}, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if want := (jsonProps{"new": "2"}); !reflect.DeepEqual(dst.Props, want) {
+		t.Fatalf("Props = %v, want %v", dst.Props, want)
+	}
+}
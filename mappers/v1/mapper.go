@@ -1,8 +1,17 @@
 package sqlcmapper
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,6 +28,16 @@ func PgUUIDToString(id pgtype.UUID) string {
 	return uuid.UUID(id.Bytes).String()
 }
 
+// PgUUIDToBytes returns a copy of the raw 16 bytes, or nil when NULL.
+func PgUUIDToBytes(id pgtype.UUID) []byte {
+	if !id.Valid {
+		return nil
+	}
+	b := make([]byte, len(id.Bytes))
+	copy(b, id.Bytes[:])
+	return b
+}
+
 func PgTextToStringPtr(txt pgtype.Text) *string {
 	if !txt.Valid {
 		return nil
@@ -26,6 +45,20 @@ func PgTextToStringPtr(txt pgtype.Text) *string {
 	return &txt.String
 }
 
+// pgx v5 scans varchar into pgtype.Text, so this is the same conversion.
+func PgVarcharToStringPtr(v pgtype.Text) *string {
+	return PgTextToStringPtr(v)
+}
+
+// PgTextToURL parses a text column with url.Parse, so relative references
+// are accepted. NULL yields nil.
+func PgTextToURL(txt pgtype.Text) (*url.URL, error) {
+	if !txt.Valid {
+		return nil, nil
+	}
+	return url.Parse(txt.String)
+}
+
 func PgFloat8ToFloat64Ptr(f pgtype.Float8) *float64 {
 	if !f.Valid {
 		return nil
@@ -33,6 +66,30 @@ func PgFloat8ToFloat64Ptr(f pgtype.Float8) *float64 {
 	return &f.Float64
 }
 
+// PgFloat8ToDecimalString formats f with prec fractional digits, or the
+// shortest exact representation when prec is -1. ok is false for NULL.
+func PgFloat8ToDecimalString(f pgtype.Float8, prec int) (string, bool) {
+	if !f.Valid {
+		return "", false
+	}
+	return strconv.FormatFloat(f.Float64, 'f', prec, 64), true
+}
+
+// PgFloat8ToInt64Ptr rounds to the nearest integer, halves away from zero
+// (2.5 -> 3, -2.5 -> -3). NULL, NaN, infinities and values outside the int64
+// range yield nil.
+func PgFloat8ToInt64Ptr(f pgtype.Float8) *int64 {
+	if !f.Valid {
+		return nil
+	}
+	r := math.Round(f.Float64)
+	if math.IsNaN(r) || r < math.MinInt64 || r >= math.MaxInt64 {
+		return nil
+	}
+	i := int64(r)
+	return &i
+}
+
 func PgInt4ToInt32Ptr(i pgtype.Int4) *int32 {
 	if !i.Valid {
 		return nil
@@ -40,6 +97,79 @@ func PgInt4ToInt32Ptr(i pgtype.Int4) *int32 {
 	return &i.Int32
 }
 
+func PgInt2ToInt16Ptr(i pgtype.Int2) *int16 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int16
+}
+
+func PgInt4ToIntPtr(i pgtype.Int4) *int {
+	if !i.Valid {
+		return nil
+	}
+	v := int(i.Int32)
+	return &v
+}
+
+func PgInt4ToInt(i pgtype.Int4, def int) int {
+	if !i.Valid {
+		return def
+	}
+	return int(i.Int32)
+}
+
+var errNegativeUnsigned = errors.New("sqlcmapper: negative value for unsigned target")
+
+// PgInt4ToUint32Ptr converts a non-negative int4 to *uint32. NULL yields nil;
+// a negative value is an error.
+func PgInt4ToUint32Ptr(i pgtype.Int4) (*uint32, error) {
+	if !i.Valid {
+		return nil, nil
+	}
+	if i.Int32 < 0 {
+		return nil, errNegativeUnsigned
+	}
+	u := uint32(i.Int32)
+	return &u, nil
+}
+
+func PgInt8ToInt64Ptr(i pgtype.Int8) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
+// PgInt8ToStringPtr renders a bigint as a decimal string, for IDs that exceed
+// the 2^53 range JavaScript clients can represent exactly.
+func PgInt8ToStringPtr(i pgtype.Int8) *string {
+	if !i.Valid {
+		return nil
+	}
+	s := strconv.FormatInt(i.Int64, 10)
+	return &s
+}
+
+// PgBoolToStringPtr renders a bool as "true" or "false", nil when NULL.
+func PgBoolToStringPtr(b pgtype.Bool) *string {
+	if !b.Valid {
+		return nil
+	}
+	s := strconv.FormatBool(b.Bool)
+	return &s
+}
+
+// PgInt4ToBoolPtr reads a legacy 0/1 integer boolean; any non-zero value is
+// true. NULL yields nil.
+func PgInt4ToBoolPtr(i pgtype.Int4) *bool {
+	if !i.Valid {
+		return nil
+	}
+	b := i.Int32 != 0
+	return &b
+}
+
 func PgBoolToBoolPtr(b pgtype.Bool) *bool {
 	if !b.Valid {
 		return nil
@@ -54,147 +184,777 @@ func PgTimestamptzToString(ts pgtype.Timestamptz) string {
 	return ts.Time.Format(time.RFC3339)
 }
 
+// PgTimestamptzToStringLayout formats ts with layout at the call site,
+// ignoring WithTimeLayout. NULL yields "".
+func PgTimestamptzToStringLayout(ts pgtype.Timestamptz, layout string) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.Format(layout)
+}
+
+func PgTimestamptzToStringNano(ts pgtype.Timestamptz) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.Format(time.RFC3339Nano)
+}
+
+func PgTimestamptzToTimePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid || ts.InfinityModifier != pgtype.Finite {
+		return nil
+	}
+	return &ts.Time
+}
+
+// PgTimestamptzToTimePtrUTC is PgTimestamptzToTimePtr with the result in
+// UTC, whatever location pgx scanned it in.
+func PgTimestamptzToTimePtrUTC(ts pgtype.Timestamptz) *time.Time {
+	t := PgTimestamptzToTimePtr(ts)
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func PgTimestamptzToTime(ts pgtype.Timestamptz, def time.Time) time.Time {
+	if t := PgTimestamptzToTimePtr(ts); t != nil {
+		return *t
+	}
+	return def
+}
+
+// PgTimestamptzToAgeString describes ts relative to now in its largest whole
+// unit: "45s ago", "3h ago", "2d ago", or "in 5m" for future times. Anything
+// under a second is "just now". NULL and infinite timestamps yield nil.
+func PgTimestamptzToAgeString(ts pgtype.Timestamptz, now time.Time) *string {
+	t := PgTimestamptzToTimePtr(ts)
+	if t == nil {
+		return nil
+	}
+	d := now.Sub(*t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	var n int64
+	var unit string
+	switch {
+	case d < time.Second:
+		s := "just now"
+		return &s
+	case d < time.Minute:
+		n, unit = int64(d/time.Second), "s"
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "m"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "h"
+	default:
+		n, unit = int64(d/(24*time.Hour)), "d"
+	}
+	s := strconv.FormatInt(n, 10) + unit + " ago"
+	if future {
+		s = "in " + strconv.FormatInt(n, 10) + unit
+	}
+	return &s
+}
+
+// Epoch-integer columns. Results are in UTC; NULL yields nil.
+
+func PgInt4ToTimePtr(i pgtype.Int4) *time.Time {
+	if !i.Valid {
+		return nil
+	}
+	t := time.Unix(int64(i.Int32), 0).UTC()
+	return &t
+}
+
+func PgInt8ToTimePtr(i pgtype.Int8) *time.Time {
+	if !i.Valid {
+		return nil
+	}
+	t := time.Unix(i.Int64, 0).UTC()
+	return &t
+}
+
+// PgInt8MillisToTimePtr reads epoch milliseconds.
+func PgInt8MillisToTimePtr(i pgtype.Int8) *time.Time {
+	if !i.Valid {
+		return nil
+	}
+	t := time.UnixMilli(i.Int64).UTC()
+	return &t
+}
+
+// PgDateToString formats a date as ISO "2006-01-02". NULL and infinite
+// dates yield "".
+func PgDateToString(d pgtype.Date) string {
+	return formatDate(d, "2006-01-02")
+}
+
+func formatDate(d pgtype.Date, layout string) string {
+	t := PgDateToTimePtr(d)
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// PgTimeToString formats a time-of-day as "15:04:05" with up to microsecond
+// precision, dropping trailing zeros ("14:30:00", "14:30:00.5").
+func PgTimeToString(t pgtype.Time) string {
+	if !t.Valid {
+		return ""
+	}
+	if t.Microseconds >= int64(24*time.Hour/time.Microsecond) {
+		return "24:00:00"
+	}
+	return time.Time{}.Add(time.Duration(t.Microseconds) * time.Microsecond).Format("15:04:05.999999")
+}
+
+func PgTimeToStringPtr(t pgtype.Time) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := PgTimeToString(t)
+	return &s
+}
+
+func PgDateToTimePtr(d pgtype.Date) *time.Time {
+	if !d.Valid || d.InfinityModifier != pgtype.Finite {
+		return nil
+	}
+	return &d.Time
+}
+
+/////////////////////
+// Pointer helpers
+/////////////////////
+
+// Coalesce returns the first non-nil pointer, or nil when all are nil.
+func Coalesce[T any](vals ...*T) *T {
+	for _, v := range vals {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// CoalesceValue returns the value behind the first non-nil pointer, or def.
+func CoalesceValue[T any](def T, vals ...*T) T {
+	if v := Coalesce(vals...); v != nil {
+		return *v
+	}
+	return def
+}
+
 /////////////////////
 // GenericMapper
 /////////////////////
 
 type GenericMapper[From any, To any] struct {
 	mapFunc func(From) To
+	ptrFunc func(From) *To
+	refFunc func(*From) To
 }
 
 func NewGenericMapper[From any, To any](fn func(From) To) *GenericMapper[From, To] {
 	return &GenericMapper[From, To]{mapFunc: fn}
 }
 
+// NewGenericMapperPtr builds a mapper whose function may return nil when the
+// source represents an absent entity. Map returns the zero To in that case;
+// use MapPtr to observe the nil.
+func NewGenericMapperPtr[From any, To any](fn func(From) *To) *GenericMapper[From, To] {
+	return &GenericMapper[From, To]{
+		mapFunc: func(f From) To {
+			if t := fn(f); t != nil {
+				return *t
+			}
+			return *new(To)
+		},
+		ptrFunc: fn,
+	}
+}
+
+// NewGenericMapperFromPtr builds a mapper whose function reads the source
+// through a pointer, so wide rows aren't copied. MapSlice passes &fs[i]
+// straight from the backing array. fn must not retain the pointer.
+func NewGenericMapperFromPtr[From any, To any](fn func(*From) To) *GenericMapper[From, To] {
+	return &GenericMapper[From, To]{
+		mapFunc: func(f From) To { return fn(&f) },
+		refFunc: fn,
+	}
+}
+
+// NewGenericMapperWithDefault builds a mapper for optional entities: fn
+// reports false when f stands for an absent entity (e.g. an all-NULL left
+// join), and Map then returns def instead. Slice methods do the same per
+// element.
+func NewGenericMapperWithDefault[From any, To any](fn func(From) (To, bool), def To) *GenericMapper[From, To] {
+	return &GenericMapper[From, To]{mapFunc: func(f From) To {
+		if t, ok := fn(f); ok {
+			return t
+		}
+		return def
+	}}
+}
+
 func (m *GenericMapper[From, To]) Map(f From) To {
 	return m.mapFunc(f)
 }
 
+// MapRef maps the value f points to without copying it when the mapper was
+// built with NewGenericMapperFromPtr.
+func (m *GenericMapper[From, To]) MapRef(f *From) To {
+	if m.refFunc != nil {
+		return m.refFunc(f)
+	}
+	return m.mapFunc(*f)
+}
+
 func (m *GenericMapper[From, To]) MapSlice(fs []From) []To {
 	out := make([]To, len(fs))
+	if m.refFunc != nil {
+		for i := range fs {
+			out[i] = m.refFunc(&fs[i])
+		}
+		return out
+	}
 	for i, f := range fs {
 		out[i] = m.mapFunc(f)
 	}
 	return out
 }
 
+// MapSliceInto maps fs into dst's backing array, growing it only when its
+// capacity is too small, and returns dst resliced to len(fs). Existing
+// contents of dst are overwritten.
+func (m *GenericMapper[From, To]) MapSliceInto(fs []From, dst []To) []To {
+	if cap(dst) < len(fs) {
+		dst = make([]To, len(fs))
+	}
+	dst = dst[:len(fs)]
+	for i := range fs {
+		dst[i] = m.MapRef(&fs[i])
+	}
+	return dst
+}
+
+func (m *GenericMapper[From, To]) MapPtr(f From) *To {
+	if m.ptrFunc != nil {
+		return m.ptrFunc(f)
+	}
+	t := m.mapFunc(f)
+	return &t
+}
+
+// MapSliceProgress is MapSlice that calls onProgress each time another whole
+// percent of fs is done, so at most 100 times, the last with done == total ==
+// len(fs); an empty slice gets that final call only. onProgress runs
+// synchronously on the calling goroutine.
+func (m *GenericMapper[From, To]) MapSliceProgress(fs []From, onProgress func(done, total int)) []To {
+	total := len(fs)
+	reported := 0 // percent last passed to onProgress
+	out := make([]To, total)
+	for i := range fs {
+		if m.refFunc != nil {
+			out[i] = m.refFunc(&fs[i])
+		} else {
+			out[i] = m.mapFunc(fs[i])
+		}
+		if done := i + 1; done < total && done*100/total > reported {
+			reported = done * 100 / total
+			onProgress(done, total)
+		}
+	}
+	onProgress(total, total)
+	return out
+}
+
+// MapSliceToPtrs maps fs and returns a pointer to each result, in order. The
+// results share one backing array, so this costs a single allocation beyond
+// the pointer slice.
+func (m *GenericMapper[From, To]) MapSliceToPtrs(fs []From) []*To {
+	vals := m.MapSlice(fs)
+	out := make([]*To, len(vals))
+	for i := range vals {
+		out[i] = &vals[i]
+	}
+	return out
+}
+
+// MapSlicePtr maps every element with MapPtr. Nil results are kept in place
+// when keepNil is true and dropped otherwise.
+func (m *GenericMapper[From, To]) MapSlicePtr(fs []From, keepNil bool) []*To {
+	out := make([]*To, 0, len(fs))
+	for _, f := range fs {
+		t := m.MapPtr(f)
+		if t == nil && !keepNil {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+/////////////////////
+// GenericMapperE
+/////////////////////
+
+// GenericMapperE is the fallible twin of GenericMapper.
+type GenericMapperE[From any, To any] struct {
+	mapFunc  func(From) (To, error)
+	validate func(To) error
+}
+
+func NewGenericMapperE[From any, To any](fn func(From) (To, error)) *GenericMapperE[From, To] {
+	return &GenericMapperE[From, To]{mapFunc: fn}
+}
+
+// WithValidation returns a copy of the mapper that checks every mapped value
+// with fn. In MapSliceE the first failure is returned as a
+// *SliceElementError carrying its index.
+func (m *GenericMapperE[From, To]) WithValidation(fn func(To) error) *GenericMapperE[From, To] {
+	return &GenericMapperE[From, To]{mapFunc: m.mapFunc, validate: fn}
+}
+
+func (m *GenericMapperE[From, To]) MapE(f From) (To, error) {
+	t, err := m.mapFunc(f)
+	if err == nil && m.validate != nil {
+		if err = m.validate(t); err != nil {
+			return *new(To), err
+		}
+	}
+	return t, err
+}
+
+// MapSliceE stops at the first failing element and reports its index.
+func (m *GenericMapperE[From, To]) MapSliceE(fs []From) ([]To, error) {
+	out := make([]To, len(fs))
+	for i, f := range fs {
+		t, err := m.mapFunc(f)
+		if err != nil {
+			return nil, fmt.Errorf("sqlcmapper: element %d: %w", i, err)
+		}
+		if m.validate != nil {
+			if err := m.validate(t); err != nil {
+				return nil, &SliceElementError{Index: i, Cause: err}
+			}
+		}
+		out[i] = t
+	}
+	return out, nil
+}
+
 /////////////////////
 // Reflection-based AutoMapWithTags
 /////////////////////
 
-func AutoMapWithTags[DB any, Model any](dbStruct DB) (Model, error) {
-	res, err := autoMapWithTagsInterface(dbStruct, reflect.TypeOf((*Model)(nil)).Elem())
+func AutoMapWithTags[DB any, Model any](dbStruct DB, opts ...Option) (Model, error) {
+	res, err := autoMapWithTagsInterface(dbStruct, reflect.TypeOf((*Model)(nil)).Elem(), newConfig(opts), true)
 	if err != nil {
 		return *new(Model), err
 	}
 	return res.Interface().(Model), nil
 }
 
-func AutoMapSliceWithTags[DB any, Model any](dbSlice []DB) ([]Model, error) {
+// AutoMapInto maps dbStruct onto the existing model at dst. Fields without a
+// matching column, or excluded by WithFieldAllowList, keep their current
+// values. A nil dbStruct pointer leaves dst untouched. On error dst may
+// already be partly updated, unless WithAtomic is set.
+func AutoMapInto[DB any, Model any](dbStruct DB, dst *Model, opts ...Option) error {
+	dbVal := reflect.ValueOf(dbStruct)
+	if dbVal.Kind() == reflect.Ptr {
+		if dbVal.IsNil() {
+			return nil
+		}
+		dbVal = dbVal.Elem()
+	}
+	cfg := newConfig(opts)
+	if !cfg.atomic {
+		return autoMapInto(dbVal, reflect.ValueOf(dst).Elem(), cfg, true)
+	}
+	// A shallow copy is enough: setters always store fresh values instead of
+	// writing through maps or pointers dst already holds.
+	tmp := *dst
+	if err := autoMapInto(dbVal, reflect.ValueOf(&tmp).Elem(), cfg, true); err != nil {
+		return err
+	}
+	*dst = tmp
+	return nil
+}
+
+// ScalarMap converts a single db value, such as the pgtype.Int8 sqlc returns
+// for SELECT count(*), with the same conversions the struct mapper applies to
+// a field: ScalarMap[pgtype.Int8, int64](n).
+func ScalarMap[DB any, To any](dbVal DB, opts ...Option) (To, error) {
+	var out To
+	dbType := reflect.TypeOf((*DB)(nil)).Elem()
+	toType := reflect.TypeOf((*To)(nil)).Elem()
+	setter := resolveSetter(dbType, toType, newConfig(opts), nil)
+	if setter == nil {
+		return out, fmt.Errorf("sqlcmapper: no conversion from %s to %s", dbType, toType)
+	}
+	if err := setter.set(reflect.ValueOf(&out).Elem(), reflect.ValueOf(&dbVal).Elem()); err != nil {
+		return out, fmt.Errorf("sqlcmapper: %w", err)
+	}
+	return out, nil
+}
+
+// AutoMapWithTagsCtx is AutoMapWithTags with a context that is handed to
+// context converters, including those used for nested structs and slices.
+func AutoMapWithTagsCtx[DB any, Model any](ctx context.Context, dbStruct DB, opts ...Option) (Model, error) {
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+	res, err := autoMapWithTagsInterface(dbStruct, reflect.TypeOf((*Model)(nil)).Elem(), cfg, true)
+	if err != nil {
+		return *new(Model), err
+	}
+	return res.Interface().(Model), nil
+}
+
+func AutoMapSliceWithTags[DB any, Model any](dbSlice []DB, opts ...Option) ([]Model, error) {
+	return autoMapSlice[DB, Model](newConfig(opts), dbSlice)
+}
+
+// AutoMapSliceWithTagsCtx is the slice form of AutoMapWithTagsCtx.
+func AutoMapSliceWithTagsCtx[DB any, Model any](ctx context.Context, dbSlice []DB, opts ...Option) ([]Model, error) {
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+	return autoMapSlice[DB, Model](cfg, dbSlice)
+}
+
+// AutoMapSliceWithTagsParallelE maps dbSlice across up to workers goroutines
+// (see MapSliceParallelE). The first failing row cancels the rest and its
+// error is returned; ctx also reaches context converters.
+func AutoMapSliceWithTagsParallelE[DB any, Model any](ctx context.Context, dbSlice []DB, workers int, opts ...Option) ([]Model, error) {
+	cfg := newConfig(opts)
+	modelType := reflect.TypeOf((*Model)(nil)).Elem()
+	// Every worker gets the same cancellable ctx; the Once publishes it to
+	// the shared config before any row is mapped.
+	var setCtx sync.Once
+	return MapSliceParallelE(ctx, dbSlice, workers, func(ctx context.Context, dbItem DB) (Model, error) {
+		setCtx.Do(func() { cfg.ctx = ctx })
+		mapped, err := autoMapWithTagsInterface(dbItem, modelType, cfg, true)
+		if err != nil {
+			return *new(Model), err
+		}
+		return mapped.Interface().(Model), nil
+	})
+}
+
+func autoMapSlice[DB any, Model any](cfg *config, dbSlice []DB) ([]Model, error) {
+	modelType := reflect.TypeOf((*Model)(nil)).Elem()
 	out := make([]Model, len(dbSlice))
 	for i, dbItem := range dbSlice {
-		mapped, err := AutoMapWithTags[DB, Model](dbItem)
+		mapped, err := autoMapWithTagsInterface(dbItem, modelType, cfg, true)
 		if err != nil {
 			return nil, err
 		}
-		out[i] = mapped
+		out[i] = mapped.Interface().(Model)
 	}
 	return out, nil
 }
 
-func autoMapWithTagsInterface(dbStruct interface{}, modelType reflect.Type) (reflect.Value, error) {
+// top is false for nested structs, whose fields WithFieldAllowList and
+// WithRequiredFields do not name.
+func autoMapWithTagsInterface(dbStruct interface{}, modelType reflect.Type, cfg *config, top bool) (reflect.Value, error) {
 	dbVal := reflect.ValueOf(dbStruct)
+	modelVal := reflect.New(modelType).Elem()
 	if dbVal.Kind() == reflect.Ptr {
+		if dbVal.IsNil() {
+			return modelVal, nil
+		}
 		dbVal = dbVal.Elem()
 	}
 
-	modelVal := reflect.New(modelType).Elem()
-
-	for i := 0; i < modelVal.NumField(); i++ {
-		field := modelVal.Field(i)
-		fieldType := modelType.Field(i)
+	return modelVal, autoMapInto(dbVal, modelVal, cfg, top)
+}
 
-		dbTag := fieldType.Tag.Get("db")
-		if dbTag == "" {
-			dbTag = fieldType.Name
+// autoMapInto maps dbVal onto the addressable struct modelVal. Fields the
+// plan does not touch keep their current values.
+func autoMapInto(dbVal, modelVal reflect.Value, cfg *config, top bool) error {
+	plan := cfg.planFor(dbVal.Type(), modelVal.Type())
+	if cfg.strict && len(plan.duplicates) > 0 {
+		if len(plan.duplicates) == 1 {
+			return plan.duplicates[0]
 		}
+		return &MapErrors{Errors: plan.duplicates}
+	}
+	return cfg.mapFields(plan, dbVal, modelVal, top)
+}
 
-		dbField := dbVal.FieldByNameFunc(func(name string) bool {
-			return name == dbTag || toSnakeCase(name) == dbTag
-		})
+// mapFields fills modelVal from dbVal following plan, which is either a
+// whole-struct plan or a prefix group's. Only top-level fields are subject
+// to WithFieldAllowList and WithRequiredFields.
+func (c *config) mapFields(plan *structPlan, dbVal, modelVal reflect.Value, top bool) error {
+	// report returns err to stop mapping, or records it and returns nil under
+	// WithCollectErrors.
+	var collected []*MapError
+	report := func(err error) error {
+		if !c.collectErrors {
+			return err
+		}
+		collected = appendMapErrors(collected, err)
+		return nil
+	}
 
-		if !dbField.IsValid() {
+	for _, fp := range plan.fields {
+		if fp.skip || (top && c.allowedFields != nil && !c.allowedFields[fp.name]) {
 			continue
 		}
-
-		switch dbField.Interface().(type) {
-		case pgtype.UUID:
-			if field.Kind() == reflect.String {
-				field.SetString(PgUUIDToString(dbField.Interface().(pgtype.UUID)))
+		if fp.methodErr != nil {
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: "method tag", Err: fp.methodErr}); err != nil {
+				return err
+			}
+			continue
+		}
+		if fp.group != nil {
+			if !fp.settable {
+				continue
 			}
-		case pgtype.Text:
-			if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String {
-				field.Set(reflect.ValueOf(PgTextToStringPtr(dbField.Interface().(pgtype.Text))))
+			if err := c.mapGroup(fp, dbVal, modelVal.Field(fp.index)); err != nil {
+				if err := report(prefixMapErrors(err, fp.name)); err != nil {
+					return err
+				}
 			}
-		case pgtype.Float8:
-			if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Float64 {
-				field.Set(reflect.ValueOf(PgFloat8ToFloat64Ptr(dbField.Interface().(pgtype.Float8))))
+			continue
+		}
+		// A required field must be bound, strict or not.
+		required := top && c.requiredFields[fp.name]
+		if fp.dbIndex == nil && fp.method == "" {
+			if required {
+				if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: "required field has no db field for column"}); err != nil {
+					return err
+				}
+				continue
 			}
-		case pgtype.Int4:
-			if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Int32 {
-				field.Set(reflect.ValueOf(PgInt4ToInt32Ptr(dbField.Interface().(pgtype.Int4))))
+			if !c.strict || !fp.settable || (c.allowMissing && fp.nearMatch == "") {
+				continue
 			}
-		case pgtype.Bool:
-			if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Bool {
-				field.Set(reflect.ValueOf(PgBoolToBoolPtr(dbField.Interface().(pgtype.Bool))))
+			reason := "no db field for column"
+			switch {
+			case c.allowMissing:
+				reason += "; did you mean db field " + fp.nearMatch + "?"
+			case len(plan.unusedDBFields) > 0:
+				reason += "; unmatched db fields: " + strings.Join(plan.unusedDBFields, ", ")
 			}
-		case pgtype.Timestamptz:
-			if field.Kind() == reflect.String {
-				field.SetString(PgTimestamptzToString(dbField.Interface().(pgtype.Timestamptz)))
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: reason}); err != nil {
+				return err
 			}
-		default:
-			if field.Kind() == reflect.Struct && dbField.Kind() == reflect.Struct {
-				mappedField, err := autoMapWithTagsInterface(dbField.Interface(), field.Type())
-				if err != nil {
-					return modelVal, err
-				}
-				field.Set(mappedField)
+			continue
+		}
+		var dbField reflect.Value
+		if fp.method != "" {
+			dbField = callDBMethod(dbVal, fp.method)
+		} else {
+			var err error
+			if dbField, err = dbVal.FieldByIndexErr(fp.dbIndex); err != nil {
 				continue
 			}
-			if field.Kind() == reflect.Slice && dbField.Kind() == reflect.Slice {
-				sliceType := field.Type().Elem()
-				mappedSlice := reflect.MakeSlice(field.Type(), dbField.Len(), dbField.Len())
-				for j := 0; j < dbField.Len(); j++ {
-					mappedElem, err := autoMapWithTagsInterface(dbField.Index(j).Interface(), sliceType)
-					if err != nil {
-						return modelVal, err
-					}
-					mappedSlice.Index(j).Set(mappedElem)
-				}
-				field.Set(mappedSlice)
+		}
+		field := modelVal.Field(fp.index)
+
+		if !fp.settable {
+			if c.ignoreUnexported && !c.strict {
 				continue
 			}
-			if dbField.Type().AssignableTo(field.Type()) {
-				field.Set(dbField)
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: fmt.Sprintf("field %s matched column %s but is not settable", fp.name, fp.column)}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if unknown := fp.opts.unknown(c); c.strict && len(unknown) > 0 {
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: "unknown tag option " + strings.Join(unknown, ", ")}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fp.setter == nil {
+			if !c.strict && !required {
+				continue
+			}
+			reason := "no conversion from " + fp.dbType.String() + " to " + field.Type().String()
+			if isPgtype(fp.dbType) {
+				reason = "unhandled " + fp.dbType.String() + " for " + field.Type().String()
+			}
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: reason}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.setField(fp, field, dbField); err != nil {
+			var mapErr *MapError
+			var mapErrs *MapErrors
+			if !errors.As(err, &mapErr) && !errors.As(err, &mapErrs) {
+				err = &MapError{Field: fp.name, Column: fp.column, Reason: "conversion failed", Err: err}
+			} else if c.errorOnPartialNested {
+				err = prefixMapErrors(err, fp.name)
+			}
+			if err := report(err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if required && isNullDBValue(dbField) && field.IsZero() {
+			if err := report(&MapError{Field: fp.name, Column: fp.column, Reason: "required field is NULL"}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(collected) > 0 {
+		return &MapErrors{Errors: collected}
+	}
+	return nil
+}
+
+// mapGroup fills a prefix-grouped field. A pointer field is set to nil when
+// every column of the group is NULL, as for the missing side of a LEFT JOIN.
+// Otherwise it gets a fresh pointer to a copy of the current pointee, so
+// fields the group does not touch keep their values without writing through
+// a pointer the caller still holds.
+func (c *config) mapGroup(fp fieldPlan, dbVal, field reflect.Value) error {
+	if field.Kind() != reflect.Ptr {
+		return c.mapFields(fp.group, dbVal, field, false)
+	}
+	if groupIsNull(fp.group, dbVal) {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	p := reflect.New(field.Type().Elem())
+	if !field.IsNil() {
+		p.Elem().Set(field.Elem())
+	}
+	err := c.mapFields(fp.group, dbVal, p.Elem(), false)
+	field.Set(p)
+	return err
+}
+
+func groupIsNull(plan *structPlan, dbVal reflect.Value) bool {
+	for _, fp := range plan.fields {
+		switch {
+		case fp.group != nil:
+			if !groupIsNull(fp.group, dbVal) {
+				return false
+			}
+		case fp.dbIndex != nil:
+			if v, err := dbVal.FieldByIndexErr(fp.dbIndex); err == nil && !isNullDBValue(v) {
+				return false
 			}
 		}
 	}
+	return true
+}
+
+// setField runs fp's setter, routing the value through a scratch copy first
+// when a WithBeforeFieldSet hook wants to see it. The copy starts from the
+// field's current value so the hook cannot change what the setter leaves.
+func (c *config) setField(fp fieldPlan, field, dbField reflect.Value) error {
+	if c.beforeFieldSet == nil {
+		return fp.setter.set(field, dbField)
+	}
+	scratch := reflect.New(field.Type()).Elem()
+	scratch.Set(field)
+	if err := fp.setter.set(scratch, dbField); err != nil {
+		return err
+	}
+	c.beforeFieldSet(fp.name, fp.column, scratch.Interface())
+	field.Set(scratch)
+	return nil
+}
 
-	return modelVal, nil
+// sourceLen returns the element count of a slice, array or pgtype.Array db
+// value.
+func sourceLen(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v.Len(), true
+	case reflect.Struct:
+		elems := v.FieldByName("Elements")
+		if elems.IsValid() && elems.Kind() == reflect.Slice {
+			return elems.Len(), true
+		}
+	}
+	return 0, false
 }
 
-// helper: CamelCase -> snake_case
+// isNullDBValue reports whether v is a SQL NULL: a nil pointer or a pgtype
+// wrapper whose Valid field is false.
+func isNullDBValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		return v.IsNil()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	valid := v.FieldByName("Valid")
+	return valid.IsValid() && valid.Kind() == reflect.Bool && !valid.Bool()
+}
+
+// canConvertKind reports whether src can be converted to dst without going
+// through reflect's surprising cases (int -> string yields a rune). Allowed
+// are numeric <-> numeric and same-kind conversions such as string <-> named
+// string or []T <-> named []T; float -> int needs WithLossyConvert.
+func canConvertKind(src, dst reflect.Type, cfg *config) bool {
+	if !src.ConvertibleTo(dst) {
+		return false
+	}
+	switch src.Kind() {
+	case reflect.String, reflect.Bool, reflect.Slice, reflect.Map:
+		return src.Kind() == dst.Kind()
+	}
+	if !isNumericKind(src.Kind()) || !isNumericKind(dst.Kind()) {
+		return false
+	}
+	if isFloatKind(src.Kind()) && !isFloatKind(dst.Kind()) {
+		return cfg.lossyConvert
+	}
+	return true
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || isFloatKind(k)
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// helper: CamelCase -> snake_case. Works on runes, so non-ASCII letters are
+// lowered correctly, and keeps runs of capitals together as one word:
+// "UserID" -> "user_id", "HTTPServer" -> "http_server". A lone "s" after
+// such a run, ending the name or followed by the next word, is read as a
+// plural rather than a new word: "UserIDs" -> "user_ids" and "IDsByUser" ->
+// "ids_by_user", not "user_i_ds" and "i_ds_by_user".
 func toSnakeCase(s string) string {
-	out := ""
-	for i, c := range s {
-		if c >= 'A' && c <= 'Z' {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
 			if i > 0 {
-				out += "_"
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				// An "s" after an acronym that ends the name or a word is a
+				// plural: "IDs" -> "ids", "IDsByUser" -> "ids_by_user".
+				if nextLower && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2])) {
+					nextLower = false
+				}
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
 			}
-			out += string(c + ('a' - 'A'))
-		} else {
-			out += string(c)
+			b.WriteRune(unicode.ToLower(r))
+			continue
 		}
+		b.WriteRune(r)
 	}
-	return out
+	return b.String()
 }
@@ -0,0 +1,825 @@
+package sqlcmapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestAutoMapIntoAtomicLeavesDstOnError(t *testing.T) {
+	orig := jsonModel{Props: jsonProps{"old": "1"}, Tags: jsonTags{"keep"}}
+	dst := jsonModel{Props: jsonProps{"old": "1"}, Tags: jsonTags{"keep"}}
+	row := jsonRow{Props: []byte(`{"new":"2"}`), Tags: []byte(`not json`)}
+	if err := AutoMapInto(row, &dst, WithAtomic()); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+	if !reflect.DeepEqual(dst, orig) {
+		t.Fatalf("dst = %+v, want unchanged %+v", dst, orig)
+	}
+}
+
+func TestAutoMapIntoWithoutAtomicIsPartial(t *testing.T) {
+	dst := jsonModel{Props: jsonProps{"old": "1"}}
+	row := jsonRow{Props: []byte(`{"new":"2"}`), Tags: []byte(`not json`)}
+	if err := AutoMapInto(row, &dst); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+	if want := (jsonProps{"new": "2"}); !reflect.DeepEqual(dst.Props, want) {
+		t.Fatalf("Props = %v, want %v", dst.Props, want)
+	}
+}
+
+func TestAutoMapIntoNilDBPointer(t *testing.T) {
+	dst := jsonModel{Props: jsonProps{"old": "1"}}
+	if err := AutoMapInto((*jsonRow)(nil), &dst); err != nil {
+		t.Fatal(err)
+	}
+	if want := (jsonModel{Props: jsonProps{"old": "1"}}); !reflect.DeepEqual(dst, want) {
+		t.Fatalf("dst = %+v, want unchanged %+v", dst, want)
+	}
+}
+
+type allowAddrRow struct {
+	Street string
+	City   string
+	Name   pgtype.Text
+}
+
+type allowRow struct {
+	ID   int64
+	Name pgtype.Text
+	Addr allowAddrRow
+}
+
+type allowAddr struct {
+	Street string
+	City   string
+	Name   string
+}
+
+type allowModel struct {
+	ID   int64
+	Name string
+	Addr allowAddr
+}
+
+func TestAutoMapIntoAllowListFillsNestedStruct(t *testing.T) {
+	row := allowRow{ID: 1, Name: pgtype.Text{String: "n", Valid: true}, Addr: allowAddrRow{Street: "s", City: "c"}}
+	dst := allowModel{ID: 9}
+	if err := AutoMapInto(row, &dst, WithFieldAllowList("Name", "Addr")); err != nil {
+		t.Fatal(err)
+	}
+	want := allowModel{ID: 9, Name: "n", Addr: allowAddr{Street: "s", City: "c"}}
+	if dst != want {
+		t.Fatalf("dst = %+v, want %+v", dst, want)
+	}
+}
+
+func TestRequiredFieldsIgnoreNestedNames(t *testing.T) {
+	row := allowRow{Name: pgtype.Text{String: "n", Valid: true}}
+	if _, err := AutoMapWithTags[allowRow, allowModel](row, WithRequiredFields("Name")); err != nil {
+		t.Fatalf("nested NULL Name reported as required: %v", err)
+	}
+}
+
+type hookRow struct {
+	Day  pgtype.Date
+	Blob []byte
+	N    pgtype.Int4
+}
+
+type hookModel struct {
+	Day  time.Time
+	Blob string
+	N    int32
+}
+
+func TestBeforeFieldSetDoesNotChangeResults(t *testing.T) {
+	seed := hookModel{Day: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Blob: "keep", N: 5}
+	rows := []hookRow{
+		{},
+		{Day: pgtype.Date{Time: time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), Valid: true}, Blob: []byte("b"), N: pgtype.Int4{Int32: 7, Valid: true}},
+	}
+	for _, row := range rows {
+		plain, hooked := seed, seed
+		if err := AutoMapInto(row, &plain); err != nil {
+			t.Fatal(err)
+		}
+		var seen []string
+		hook := WithBeforeFieldSet(func(field, column string, value any) {
+			seen = append(seen, field)
+		})
+		if err := AutoMapInto(row, &hooked, hook); err != nil {
+			t.Fatal(err)
+		}
+		if plain != hooked {
+			t.Fatalf("with hook %+v, without %+v", hooked, plain)
+		}
+		if len(seen) != 3 {
+			t.Fatalf("hook saw %v, want all three fields", seen)
+		}
+	}
+}
+
+type requiredRow struct {
+	Name  pgtype.Text
+	Flags chan int
+}
+
+type requiredModel struct {
+	Name    string
+	Flags   []string
+	Missing string
+}
+
+func TestRequiredFields(t *testing.T) {
+	row := requiredRow{Name: pgtype.Text{String: "n", Valid: true}}
+	if _, err := AutoMapWithTags[requiredRow, requiredModel](row, WithRequiredFields("Name")); err != nil {
+		t.Fatalf("non-NULL required field: %v", err)
+	}
+
+	for _, tc := range []struct {
+		field  string
+		row    requiredRow
+		reason string
+	}{
+		{"Name", requiredRow{}, "required field is NULL"},
+		{"Missing", row, "required field has no db field for column"},
+		{"Flags", row, "no conversion from chan int"},
+	} {
+		_, err := AutoMapWithTags[requiredRow, requiredModel](tc.row, WithRequiredFields(tc.field))
+		var mapErr *MapError
+		if !errors.As(err, &mapErr) || mapErr.Field != tc.field || !strings.HasPrefix(mapErr.Reason, tc.reason) {
+			t.Errorf("%s: err = %v, want reason %q", tc.field, err, tc.reason)
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           "",
+		"ID":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"IDs":        "ids",
+		"UserIDs":    "user_ids",
+		"IDsByUser":  "ids_by_user",
+		"URLsFor2":   "urls_for2",
+		"HTTPStatus": "http_status",
+		"IDsAPIs":    "ids_apis",
+		"Address2":   "address2",
+		"V2Name":     "v2_name",
+		"ÜberName":   "über_name",
+		"already_ok": "already_ok",
+	} {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func FuzzToSnakeCase(f *testing.F) {
+	for _, seed := range []string{"", "UserID", "HTTPServer", "IDs", "ÜberName", "aB1C", "ǅemal"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			return
+		}
+		once := toSnakeCase(s)
+		if !utf8.ValidString(once) {
+			t.Fatalf("toSnakeCase(%q) = %q is not valid UTF-8", s, once)
+		}
+		if twice := toSnakeCase(once); twice != once {
+			t.Fatalf("not stable: %q -> %q -> %q", s, once, twice)
+		}
+	})
+}
+
+type privateRow struct {
+	Name   string
+	secret string
+	Secret string
+}
+
+type privateModel struct {
+	Name   string
+	secret string
+}
+
+func TestUnexportedModelFieldIsSkipped(t *testing.T) {
+	row := privateRow{Name: "n", secret: "x", Secret: "s"}
+	got, err := AutoMapWithTags[privateRow, privateModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "n" || got.secret != "" {
+		t.Fatalf("got %+v, want Name mapped and secret left alone", got)
+	}
+
+	for _, opt := range []Option{WithIgnoreUnexported(false), WithStrict()} {
+		_, err := AutoMapWithTags[privateRow, privateModel](row, opt)
+		var mapErr *MapError
+		if !errors.As(err, &mapErr) || mapErr.Field != "secret" {
+			t.Errorf("err = %v, want a MapError for secret", err)
+		}
+	}
+}
+
+func TestPgInt8ToStringPtr(t *testing.T) {
+	const big = int64(1)<<53 + 1
+	if s := PgInt8ToStringPtr(pgtype.Int8{Int64: big, Valid: true}); s == nil || *s != "9007199254740993" {
+		t.Fatalf("got %v, want 9007199254740993", s)
+	}
+	if s := PgInt8ToStringPtr(pgtype.Int8{Int64: -big, Valid: true}); s == nil || *s != "-9007199254740993" {
+		t.Fatalf("got %v, want -9007199254740993", s)
+	}
+	if PgInt8ToStringPtr(pgtype.Int8{}) != nil {
+		t.Fatal("NULL should give nil")
+	}
+}
+
+type int8Row struct {
+	ID     pgtype.Int8
+	Parent pgtype.Int8
+}
+
+type int8TagModel struct {
+	ID     string  `db:"ID,asstring"`
+	Parent *string `db:"Parent,asstring"`
+}
+
+type int8Model struct {
+	ID     string
+	Parent *string
+}
+
+func TestAutoMapInt8AsString(t *testing.T) {
+	row := int8Row{ID: pgtype.Int8{Int64: 1<<62 + 7, Valid: true}}
+	tagged, err := AutoMapWithTags[int8Row, int8TagModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged.ID != "4611686018427387911" || tagged.Parent != nil {
+		t.Fatalf("tag hint: got %q, %v", tagged.ID, tagged.Parent)
+	}
+
+	global, err := AutoMapWithTags[int8Row, int8Model](row, WithInt8AsString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if global.ID != "4611686018427387911" || global.Parent != nil {
+		t.Fatalf("WithInt8AsString: got %q, %v", global.ID, global.Parent)
+	}
+}
+
+func TestPgFloat8ToInt64PtrRounding(t *testing.T) {
+	for in, want := range map[float64]int64{
+		2.5: 3, -2.5: -3, 0.5: 1, -0.5: -1, 1.4999: 1, 3.5: 4, 2.4: 2,
+	} {
+		got := PgFloat8ToInt64Ptr(pgtype.Float8{Float64: in, Valid: true})
+		if got == nil || *got != want {
+			t.Errorf("%v: got %v, want %d", in, got, want)
+		}
+	}
+	for _, in := range []pgtype.Float8{{}, {Float64: math.NaN(), Valid: true}, {Float64: math.Inf(1), Valid: true}, {Float64: 1e19, Valid: true}} {
+		if got := PgFloat8ToInt64Ptr(in); got != nil {
+			t.Errorf("%v: got %d, want nil", in, *got)
+		}
+	}
+}
+
+type roundRow struct {
+	Score pgtype.Float8
+	Avg   pgtype.Float8
+}
+
+type roundModel struct {
+	Score int64  `db:"Score,round"`
+	Avg   *int64 `db:"Avg,round"`
+}
+
+func TestAutoMapRoundTag(t *testing.T) {
+	got, err := AutoMapWithTags[roundRow, roundModel](roundRow{Score: pgtype.Float8{Float64: -7.5, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Score != -8 || got.Avg != nil {
+		t.Fatalf("got %d, %v; want -8 and nil", got.Score, got.Avg)
+	}
+
+	nan := roundRow{Score: pgtype.Float8{Float64: math.NaN(), Valid: true}}
+	if got, err := AutoMapWithTags[roundRow, roundModel](nan); err != nil || got.Score != 0 {
+		t.Fatalf("NaN without strict: got %d, %v", got.Score, err)
+	}
+	if _, err := AutoMapWithTags[roundRow, roundModel](nan, WithStrict()); err == nil {
+		t.Fatal("NaN under WithStrict should be an error")
+	}
+}
+
+func TestPgFloat8ToDecimalString(t *testing.T) {
+	f := pgtype.Float8{Float64: 3.14159265, Valid: true}
+	for prec, want := range map[int]string{0: "3", 5: "3.14159", -1: "3.14159265"} {
+		if got, ok := PgFloat8ToDecimalString(f, prec); !ok || got != want {
+			t.Errorf("prec %d: got %q, %v; want %q", prec, got, ok, want)
+		}
+	}
+	if got, ok := PgFloat8ToDecimalString(pgtype.Float8{Float64: 2.5, Valid: true}, 0); !ok || got != "2" {
+		t.Errorf("prec 0 of 2.5: got %q, want FormatFloat's round-half-even \"2\"", got)
+	}
+	if _, ok := PgFloat8ToDecimalString(pgtype.Float8{}, 2); ok {
+		t.Error("NULL should report ok=false")
+	}
+}
+
+type precRow struct {
+	Value   pgtype.Float8
+	Reading pgtype.Float8
+	Raw     pgtype.Float8
+}
+
+type precModel struct {
+	Value   string  `db:"Value,prec=0"`
+	Reading *string `db:"Reading,prec=5"`
+	Raw     string  `db:"Raw,prec"`
+}
+
+func TestAutoMapPrecTag(t *testing.T) {
+	f := pgtype.Float8{Float64: 1.23456789, Valid: true}
+	got, err := AutoMapWithTags[precRow, precModel](precRow{Value: f, Reading: f, Raw: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value != "1" || got.Reading == nil || *got.Reading != "1.23457" || got.Raw != "1.23456789" {
+		t.Fatalf("got %q, %v, %q", got.Value, got.Reading, got.Raw)
+	}
+
+	got, err = AutoMapWithTags[precRow, precModel](precRow{})
+	if err != nil || got.Value != "" || got.Reading != nil {
+		t.Fatalf("NULL: got %+v, %v", got, err)
+	}
+}
+
+func TestMapSliceInto(t *testing.T) {
+	m := NewGenericMapper(func(i int) string { return strconv.Itoa(i) })
+	buf := make([]string, 1, 4)
+	buf[0] = "stale"
+
+	got := m.MapSliceInto([]int{1, 2, 3}, buf)
+	if !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Fatalf("got %q", got)
+	}
+	if &got[0] != &buf[:1][0] {
+		t.Fatal("dst's backing array should be reused when it is large enough")
+	}
+
+	got = m.MapSliceInto([]int{1, 2, 3, 4, 5}, buf)
+	if len(got) != 5 || got[4] != "5" {
+		t.Fatalf("grown: got %q", got)
+	}
+	if got = m.MapSliceInto(nil, buf); len(got) != 0 {
+		t.Fatalf("empty input: got %q", got)
+	}
+}
+
+func BenchmarkMapSlice(b *testing.B) {
+	m := NewGenericMapper(func(i int) int64 { return int64(i) * 2 })
+	in := make([]int, 1024)
+	b.Run("MapSlice", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = m.MapSlice(in)
+		}
+	})
+	b.Run("MapSliceInto", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []int64
+		for b.Loop() {
+			buf = m.MapSliceInto(in, buf)
+		}
+	})
+}
+
+func TestPgBoolToStringPtr(t *testing.T) {
+	if s := PgBoolToStringPtr(pgtype.Bool{Bool: true, Valid: true}); s == nil || *s != "true" {
+		t.Errorf("true: got %v", s)
+	}
+	if s := PgBoolToStringPtr(pgtype.Bool{Valid: true}); s == nil || *s != "false" {
+		t.Errorf("false: got %v", s)
+	}
+	if PgBoolToStringPtr(pgtype.Bool{}) != nil {
+		t.Error("NULL should give nil")
+	}
+}
+
+type boolstrRow struct {
+	Enabled pgtype.Bool
+	Beta    pgtype.Bool
+}
+
+type boolstrModel struct {
+	Enabled string  `db:"Enabled,boolstr"`
+	Beta    *string `db:"Beta,boolstr"`
+}
+
+func TestAutoMapBoolstrTag(t *testing.T) {
+	row := boolstrRow{Enabled: pgtype.Bool{Bool: true, Valid: true}, Beta: pgtype.Bool{Valid: true}}
+	got, err := AutoMapWithTags[boolstrRow, boolstrModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Enabled != "true" || got.Beta == nil || *got.Beta != "false" {
+		t.Fatalf("got %q, %v", got.Enabled, got.Beta)
+	}
+
+	keep := "keep"
+	dst := boolstrModel{Enabled: "keep", Beta: &keep}
+	if err := AutoMapInto(boolstrRow{}, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Enabled != "" || dst.Beta != nil {
+		t.Fatalf("NULL: got %q, %v; want \"\" and nil", dst.Enabled, dst.Beta)
+	}
+}
+
+func TestPgUUIDToBytesIsIndependent(t *testing.T) {
+	id := pgtype.UUID{Bytes: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, Valid: true}
+	b := PgUUIDToBytes(id)
+	if len(b) != 16 || b[0] != 1 || b[15] != 16 {
+		t.Fatalf("got %v", b)
+	}
+	b[0] = 0xff
+	if id.Bytes[0] != 1 {
+		t.Fatal("mutating the result changed the source UUID")
+	}
+	if again := PgUUIDToBytes(id); again[0] != 1 {
+		t.Fatal("results should not share storage")
+	}
+	if PgUUIDToBytes(pgtype.UUID{}) != nil {
+		t.Fatal("NULL should give nil")
+	}
+}
+
+type uuidRow struct {
+	ID    pgtype.UUID
+	Raw   pgtype.UUID
+	Other pgtype.UUID
+}
+
+type uuidModel struct {
+	ID    [16]byte
+	Raw   []byte
+	Other uuid.UUID
+}
+
+func TestAutoMapUUIDBytes(t *testing.T) {
+	id := pgtype.UUID{Bytes: uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), Valid: true}
+	row := uuidRow{ID: id, Raw: id, Other: id}
+	got, err := AutoMapWithTags[uuidRow, uuidModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != id.Bytes || !bytes.Equal(got.Raw, id.Bytes[:]) || got.Other.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestPgInt4ToUint32Ptr(t *testing.T) {
+	if u, err := PgInt4ToUint32Ptr(pgtype.Int4{Int32: 8080, Valid: true}); err != nil || u == nil || *u != 8080 {
+		t.Errorf("got %v, %v", u, err)
+	}
+	if u, err := PgInt4ToUint32Ptr(pgtype.Int4{Int32: -1, Valid: true}); !errors.Is(err, errNegativeUnsigned) || u != nil {
+		t.Errorf("negative: got %v, %v", u, err)
+	}
+	if u, err := PgInt4ToUint32Ptr(pgtype.Int4{}); err != nil || u != nil {
+		t.Errorf("NULL: got %v, %v", u, err)
+	}
+}
+
+type unsignedRow struct {
+	Port  pgtype.Int4
+	Count pgtype.Int8
+	Small pgtype.Int4
+}
+
+type unsignedModel struct {
+	Port  *uint32
+	Count *uint64
+	Small uint8
+}
+
+func TestAutoMapUnsignedTargets(t *testing.T) {
+	row := unsignedRow{Port: pgtype.Int4{Int32: 443, Valid: true}, Count: pgtype.Int8{Int64: 1 << 40, Valid: true}, Small: pgtype.Int4{Int32: 200, Valid: true}}
+	got, err := AutoMapWithTags[unsignedRow, unsignedModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got.Port != 443 || *got.Count != 1<<40 || got.Small != 200 {
+		t.Fatalf("got %d, %d, %d", *got.Port, *got.Count, got.Small)
+	}
+
+	neg := unsignedRow{Port: pgtype.Int4{Int32: -5, Valid: true}, Small: pgtype.Int4{Int32: 300, Valid: true}}
+	if _, err := AutoMapWithTags[unsignedRow, unsignedModel](neg, WithStrict()); !errors.Is(err, errNegativeUnsigned) {
+		t.Fatalf("negative under WithStrict: err = %v", err)
+	}
+	got, err = AutoMapWithTags[unsignedRow, unsignedModel](neg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got.Port != 0 || got.Small != 255 || got.Count != nil {
+		t.Fatalf("clamped: got %d, %d, %v; want 0, 255, nil", *got.Port, got.Small, got.Count)
+	}
+}
+
+func TestPgTextToURL(t *testing.T) {
+	u, err := PgTextToURL(pgtype.Text{String: "https://example.com/hook?x=1", Valid: true})
+	if err != nil || u.Host != "example.com" || u.Query().Get("x") != "1" {
+		t.Errorf("absolute: got %v, %v", u, err)
+	}
+	u, err = PgTextToURL(pgtype.Text{String: "../hooks/42", Valid: true})
+	if err != nil || u.IsAbs() || u.Path != "../hooks/42" {
+		t.Errorf("relative: got %v, %v", u, err)
+	}
+	if _, err := PgTextToURL(pgtype.Text{String: "http://[::1", Valid: true}); err == nil {
+		t.Error("invalid: want an error")
+	}
+	if u, err := PgTextToURL(pgtype.Text{}); u != nil || err != nil {
+		t.Errorf("NULL: got %v, %v", u, err)
+	}
+}
+
+type urlRow struct{ Endpoint pgtype.Text }
+
+type urlModel struct{ Endpoint *url.URL }
+
+func TestAutoMapURL(t *testing.T) {
+	got, err := AutoMapWithTags[urlRow, urlModel](urlRow{Endpoint: pgtype.Text{String: "/relative/path", Valid: true}})
+	if err != nil || got.Endpoint == nil || got.Endpoint.Path != "/relative/path" {
+		t.Fatalf("relative: got %v, %v", got.Endpoint, err)
+	}
+
+	bad := urlRow{Endpoint: pgtype.Text{String: "http://[::1", Valid: true}}
+	got, err = AutoMapWithTags[urlRow, urlModel](bad)
+	if err != nil || got.Endpoint != nil {
+		t.Fatalf("invalid without strict: got %v, %v; want nil", got.Endpoint, err)
+	}
+	if _, err := AutoMapWithTags[urlRow, urlModel](bad, WithStrict()); err == nil {
+		t.Fatal("invalid under WithStrict: want an error")
+	}
+}
+
+func TestPgTimeToString(t *testing.T) {
+	for _, tc := range []struct {
+		micros int64
+		want   string
+	}{
+		{(14*3600 + 30*60) * 1e6, "14:30:00"},
+		{(14*3600+30*60)*1e6 + 500000, "14:30:00.5"},
+		{(14*3600+30*60)*1e6 + 123456, "14:30:00.123456"},
+		{(14*3600+30*60)*1e6 + 120, "14:30:00.00012"},
+	} {
+		if got := PgTimeToString(pgtype.Time{Microseconds: tc.micros, Valid: true}); got != tc.want {
+			t.Errorf("%d: got %q, want %q", tc.micros, got, tc.want)
+		}
+	}
+	if got := PgTimeToStringPtr(pgtype.Time{}); got != nil {
+		t.Fatalf("NULL: got %q, want nil", *got)
+	}
+}
+
+func TestAutoMapTimeToStringPtr(t *testing.T) {
+	type row struct{ At, Nil pgtype.Time }
+	type model struct{ At, Nil *string }
+	got, err := AutoMapWithTags[row, model](row{At: pgtype.Time{Microseconds: 9 * 3600 * 1e6, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.At == nil || *got.At != "09:00:00" || got.Nil != nil {
+		t.Fatalf("got At=%v Nil=%v", show(got.At), show(got.Nil))
+	}
+}
+
+type optionalRow struct {
+	Name  pgtype.Text
+	Count pgtype.Int4
+}
+
+type optionalModel struct {
+	Name    Optional[string] `json:"name,omitzero"`
+	Count   Optional[int32]  `json:"count,omitzero"`
+	Comment Optional[string] `json:"comment,omitzero"`
+}
+
+func TestAutoMapOptionalPresentVersusValid(t *testing.T) {
+	row := optionalRow{Count: pgtype.Int4{Int32: 3, Valid: true}}
+	got, err := AutoMapWithTags[optionalRow, optionalModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := optionalModel{Name: Null[string](), Count: Some[int32](3)}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got.Name.Ptr() != nil || got.Comment.Ptr() != nil || *got.Count.Ptr() != 3 {
+		t.Fatalf("Ptr: Name %v, Comment %v, Count %v", show(got.Name.Ptr()), show(got.Comment.Ptr()), show(got.Count.Ptr()))
+	}
+}
+
+func TestOptionalJSONRoundTrip(t *testing.T) {
+	in := optionalModel{Name: Null[string](), Count: Some[int32](3)}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":null,"count":3}`; string(b) != want {
+		t.Fatalf("Marshal = %s, want %s", b, want)
+	}
+	var out optionalModel
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Fatalf("Unmarshal = %+v, want %+v", out, in)
+	}
+
+	// Unmarshalling over a populated value still resets absent-versus-null
+	// for the keys that are present.
+	out = optionalModel{Name: Some("old")}
+	if err := json.Unmarshal([]byte(`{"name": null}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != Null[string]() {
+		t.Fatalf("Name = %+v, want NULL", out.Name)
+	}
+	if err := json.Unmarshal([]byte(`{"count":"x"}`), &out); err == nil {
+		t.Fatal("expected an error for a mistyped value")
+	}
+}
+
+type userNullable[T any] struct {
+	Value T
+	Valid bool
+}
+
+type userMaybe[T any] struct {
+	Set bool
+	V   T
+	Tag string
+}
+
+type nullableRow struct {
+	Name  pgtype.Text
+	Count pgtype.Int8
+	Code  pgtype.Text
+}
+
+type nullableModel struct {
+	Name  userNullable[string]
+	Count userNullable[int64]
+	Code  userMaybe[string]
+}
+
+func TestAutoMapNullableWrapperByShape(t *testing.T) {
+	row := nullableRow{Name: pgtype.Text{String: "ann", Valid: true}}
+	got, err := AutoMapWithTags[nullableRow, nullableModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != (userNullable[string]{Value: "ann", Valid: true}) || got.Count != (userNullable[int64]{}) {
+		t.Fatalf("got Name %+v, Count %+v", got.Name, got.Count)
+	}
+	if got.Code != (userMaybe[string]{}) {
+		t.Fatalf("Code = %+v, want it unmapped before registration", got.Code)
+	}
+}
+
+func TestRegisterNullableWrapper(t *testing.T) {
+	RegisterNullableWrapper[userMaybe[string]]("V", "Set")
+	defer func() {
+		nullableWrappers.Delete(reflect.TypeOf(userMaybe[string]{}))
+		registrations.Add(1)
+	}()
+
+	got, err := AutoMapWithTags[nullableRow, nullableModel](nullableRow{Code: pgtype.Text{String: "x", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != (userMaybe[string]{V: "x", Set: true}) {
+		t.Fatalf("Code = %+v, want V and Set filled", got.Code)
+	}
+}
+
+func TestRegisterNullableWrapperPanics(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		register func()
+		want     string
+	}{
+		{"not a struct", func() { RegisterNullableWrapper[int]("Value", "Valid") }, "is not a struct"},
+		{"no value field", func() { RegisterNullableWrapper[userMaybe[string]]("Missing", "Set") }, "has no field Missing"},
+		{"validity not bool", func() { RegisterNullableWrapper[userMaybe[string]]("V", "Tag") }, "has no bool field Tag"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, tc.want) {
+					t.Fatalf("panic = %q, want it to mention %q", msg, tc.want)
+				}
+			}()
+			tc.register()
+		})
+	}
+}
+
+var errOdd = errors.New("odd")
+
+func halveEven(i int) (int, error) {
+	if i%2 != 0 {
+		return 0, errOdd
+	}
+	return i / 2, nil
+}
+
+func TestGenericMapperE(t *testing.T) {
+	m := NewGenericMapperE(halveEven)
+	if got, err := m.MapE(4); err != nil || got != 2 {
+		t.Fatalf("MapE(4) = %d, %v; want 2", got, err)
+	}
+	if _, err := m.MapE(3); !errors.Is(err, errOdd) {
+		t.Fatalf("MapE(3) err = %v, want %v", err, errOdd)
+	}
+
+	got, err := m.MapSliceE([]int{2, 4, 6})
+	if err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("MapSliceE = %v, %v; want [1 2 3]", got, err)
+	}
+	got, err = m.MapSliceE([]int{2, 3, 5})
+	if got != nil || !errors.Is(err, errOdd) || !strings.Contains(err.Error(), "element 1") {
+		t.Fatalf("MapSliceE = %v, %v; want nil and the element 1 error", got, err)
+	}
+}
+
+func TestGenericMapperEWithValidation(t *testing.T) {
+	errTooBig := errors.New("too big")
+	base := NewGenericMapperE(halveEven)
+	m := base.WithValidation(func(i int) error {
+		if i > 2 {
+			return errTooBig
+		}
+		return nil
+	})
+
+	if _, err := m.MapE(8); !errors.Is(err, errTooBig) {
+		t.Fatalf("MapE(8) err = %v, want %v", err, errTooBig)
+	}
+	if _, err := base.MapE(8); err != nil {
+		t.Fatalf("WithValidation changed the original mapper: %v", err)
+	}
+
+	_, err := m.MapSliceE([]int{2, 4, 8, 10})
+	var elemErr *SliceElementError
+	if !errors.As(err, &elemErr) || elemErr.Index != 2 || !errors.Is(err, errTooBig) {
+		t.Fatalf("err = %v, want a SliceElementError at index 2", err)
+	}
+
+	// Mapping errors come first and are not SliceElementErrors.
+	_, err = m.MapSliceE([]int{3})
+	if errors.As(err, &elemErr) || !errors.Is(err, errOdd) {
+		t.Fatalf("err = %v, want the mapping error", err)
+	}
+}
+
+func TestMapSliceProgressCallbacks(t *testing.T) {
+	m := NewGenericMapper(func(i int) int { return i })
+	for _, tc := range []struct{ total, calls int }{
+		{0, 1},
+		{5, 5},
+		{100, 100},
+		{150, 100},
+		{199, 100},
+		{10_000, 100},
+	} {
+		calls, last := 0, 0
+		m.MapSliceProgress(make([]int, tc.total), func(done, total int) {
+			calls++
+			if done <= last && total > 0 || total != tc.total {
+				t.Errorf("total %d: callback (%d, %d) after %d", tc.total, done, total, last)
+			}
+			last = done
+		})
+		if calls != tc.calls || last != tc.total {
+			t.Errorf("total %d: %d callbacks ending at %d, want %d ending at %d", tc.total, calls, last, tc.calls, tc.total)
+		}
+	}
+}
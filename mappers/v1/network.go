@@ -0,0 +1,170 @@
+package sqlcmapper
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+/////////////////////
+// Network helpers
+/////////////////////
+
+// pgx v5 has no pgtype.CIDR; cidr columns scan into netip.Prefix (or
+// *netip.Prefix when nullable), so these helpers take that type.
+
+// NetmaskParts is a cidr value split into network address and prefix length.
+// Any struct with the same two fields can be used as an auto-mapper target.
+type NetmaskParts struct {
+	Network string
+	Bits    int
+}
+
+func PgCIDRToNetmaskParts(c netip.Prefix) (network string, bits int, ok bool) {
+	if !c.IsValid() {
+		return "", 0, false
+	}
+	return c.Masked().Addr().String(), c.Bits(), true
+}
+
+// inet columns scan into netip.Prefix too (pgx also accepts netip.Addr for
+// host-only values). Postgres prints an inet host address without its /32 or
+// /128 suffix, and PgInetToString does the same; cidr always keeps it. An
+// invalid (zero) value means NULL.
+
+func PgInetToString(p netip.Prefix) string {
+	if !p.IsValid() {
+		return ""
+	}
+	if p.Bits() == p.Addr().BitLen() {
+		return p.Addr().String()
+	}
+	return p.String()
+}
+
+func PgInetToStringPtr(p netip.Prefix) *string {
+	if !p.IsValid() {
+		return nil
+	}
+	s := PgInetToString(p)
+	return &s
+}
+
+func PgCIDRToString(c netip.Prefix) string {
+	if !c.IsValid() {
+		return ""
+	}
+	return c.String()
+}
+
+func PgCIDRToStringPtr(c netip.Prefix) *string {
+	if !c.IsValid() {
+		return nil
+	}
+	s := c.String()
+	return &s
+}
+
+// macaddr columns scan into net.HardwareAddr; nil or empty means NULL.
+
+func PgMacaddrToString(mac net.HardwareAddr) string {
+	if len(mac) == 0 {
+		return ""
+	}
+	return mac.String()
+}
+
+func PgMacaddrToStringPtr(mac net.HardwareAddr) *string {
+	if len(mac) == 0 {
+		return nil
+	}
+	s := mac.String()
+	return &s
+}
+
+var (
+	hardwareAddrType   = reflect.TypeOf(net.HardwareAddr(nil))
+	netipPrefixType    = reflect.TypeOf(netip.Prefix{})
+	netipPrefixPtrType = reflect.TypeOf((*netip.Prefix)(nil))
+	netipAddrType      = reflect.TypeOf(netip.Addr{})
+	netipAddrPtrType   = reflect.TypeOf((*netip.Addr)(nil))
+	netmaskPartsType   = reflect.TypeOf(NetmaskParts{})
+)
+
+func isInetType(t reflect.Type) bool {
+	switch t {
+	case netipPrefixType, netipPrefixPtrType, netipAddrType, netipAddrPtrType:
+		return true
+	}
+	return false
+}
+
+// setInetStringField stores an inet/cidr db value into a string or *string
+// field using the inet rendering; NULL gives "" or nil.
+func setInetStringField(field, dbField reflect.Value) error {
+	if dbField.Kind() == reflect.Ptr {
+		if dbField.IsNil() {
+			_, err := setStringOrPtr(field, "", true)
+			return err
+		}
+		dbField = dbField.Elem()
+	}
+	var str *string
+	switch v := dbField.Interface().(type) {
+	case netip.Prefix:
+		str = PgInetToStringPtr(v)
+	case netip.Addr:
+		if v.IsValid() {
+			s := v.String()
+			str = &s
+		}
+	}
+	if str == nil {
+		_, err := setStringOrPtr(field, "", true)
+		return err
+	}
+	_, err := setStringOrPtr(field, *str, false)
+	return err
+}
+
+// setNetmaskPartsField fills a NetmaskParts-shaped struct (or pointer to one)
+// from a netip.Prefix or *netip.Prefix db value.
+func setNetmaskPartsField(field, dbField reflect.Value) bool {
+	target := field.Type()
+	isPtr := target.Kind() == reflect.Ptr
+	if isPtr {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct || !netmaskPartsType.ConvertibleTo(target) {
+		return false
+	}
+
+	var prefix netip.Prefix
+	switch dbField.Type() {
+	case netipPrefixType:
+		prefix = dbField.Interface().(netip.Prefix)
+	case netipPrefixPtrType:
+		if !dbField.IsNil() {
+			prefix = *dbField.Interface().(*netip.Prefix)
+		}
+	default:
+		return false
+	}
+
+	network, bits, ok := PgCIDRToNetmaskParts(prefix)
+	if !ok {
+		if isPtr {
+			field.Set(reflect.Zero(field.Type()))
+		}
+		return true
+	}
+	parts := reflect.ValueOf(NetmaskParts{Network: network, Bits: bits}).Convert(target)
+	if isPtr {
+		p := reflect.New(target)
+		p.Elem().Set(parts)
+		field.Set(p)
+	} else {
+		field.Set(parts)
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package sqlcmapper
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestPgCIDRToNetmaskParts(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		network string
+		bits    int
+	}{
+		{"10.1.2.0/24", "10.1.2.0", 24},
+		{"192.168.1.7/16", "192.168.0.0", 16},
+		{"2001:db8::/32", "2001:db8::", 32},
+		{"2001:db8:abcd:12::1/64", "2001:db8:abcd:12::", 64},
+		{"::1/128", "::1", 128},
+	} {
+		network, bits, ok := PgCIDRToNetmaskParts(netip.MustParsePrefix(tc.in))
+		if !ok || network != tc.network || bits != tc.bits {
+			t.Errorf("%s: got %q, %d, %v; want %q, %d", tc.in, network, bits, ok, tc.network, tc.bits)
+		}
+	}
+	if _, _, ok := PgCIDRToNetmaskParts(netip.Prefix{}); ok {
+		t.Error("an invalid prefix should report ok=false")
+	}
+}
+
+type netmaskRow struct {
+	Net  netip.Prefix
+	Opt  *netip.Prefix
+	None *netip.Prefix
+}
+
+type netmaskTarget struct {
+	Network string
+	Bits    int
+}
+
+type netmaskModel struct {
+	Net  NetmaskParts
+	Opt  *netmaskTarget
+	None *NetmaskParts
+}
+
+func TestAutoMapNetmaskParts(t *testing.T) {
+	v6 := netip.MustParsePrefix("2001:db8::/48")
+	row := netmaskRow{Net: netip.MustParsePrefix("10.0.0.0/8"), Opt: &v6}
+	got, err := AutoMapWithTags[netmaskRow, netmaskModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Net != (NetmaskParts{Network: "10.0.0.0", Bits: 8}) {
+		t.Errorf("Net = %+v", got.Net)
+	}
+	if got.Opt == nil || *got.Opt != (netmaskTarget{Network: "2001:db8::", Bits: 48}) {
+		t.Errorf("Opt = %+v", got.Opt)
+	}
+	if got.None != nil {
+		t.Errorf("None = %+v, want nil for NULL", got.None)
+	}
+}
+
+func TestPgMacaddrToString(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x1a, 0x2B, 0x3c, 0x4D, 0x5e}
+	if got := PgMacaddrToString(mac); got != "00:1a:2b:3c:4d:5e" {
+		t.Errorf("got %q", got)
+	}
+	if p := PgMacaddrToStringPtr(mac); p == nil || *p != "00:1a:2b:3c:4d:5e" {
+		t.Errorf("ptr: got %v", p)
+	}
+	if PgMacaddrToString(nil) != "" || PgMacaddrToStringPtr(nil) != nil {
+		t.Error("NULL should give \"\" and nil")
+	}
+}
+
+type macRow struct {
+	MAC net.HardwareAddr
+	Alt net.HardwareAddr
+}
+
+type macModel struct {
+	MAC string
+	Alt *string
+}
+
+func TestAutoMapMacaddr(t *testing.T) {
+	mac, err := net.ParseMAC("08:00:2b:01:02:03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := AutoMapWithTags[macRow, macModel](macRow{MAC: mac}, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MAC != "08:00:2b:01:02:03" || got.Alt != nil {
+		t.Fatalf("got %q, %v", got.MAC, got.Alt)
+	}
+}
+
+func TestPgInetAndCIDRStrings(t *testing.T) {
+	host := netip.MustParsePrefix("192.0.2.1/32")
+	subnet := netip.MustParsePrefix("192.0.2.0/24")
+	v6 := netip.MustParsePrefix("2001:db8::1/128")
+	for _, tc := range []struct {
+		got, want string
+	}{
+		{PgInetToString(host), "192.0.2.1"},
+		{PgInetToString(subnet), "192.0.2.0/24"},
+		{PgInetToString(v6), "2001:db8::1"},
+		{PgCIDRToString(host), "192.0.2.1/32"},
+		{PgCIDRToString(subnet), "192.0.2.0/24"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("got %q, want %q", tc.got, tc.want)
+		}
+	}
+	if p := PgInetToStringPtr(host); p == nil || *p != "192.0.2.1" {
+		t.Errorf("PgInetToStringPtr = %v", p)
+	}
+	if p := PgCIDRToStringPtr(subnet); p == nil || *p != "192.0.2.0/24" {
+		t.Errorf("PgCIDRToStringPtr = %v", p)
+	}
+}
+
+func TestPgInetAndCIDRNull(t *testing.T) {
+	if PgInetToString(netip.Prefix{}) != "" || PgCIDRToString(netip.Prefix{}) != "" {
+		t.Error("NULL should give \"\"")
+	}
+	if PgInetToStringPtr(netip.Prefix{}) != nil || PgCIDRToStringPtr(netip.Prefix{}) != nil {
+		t.Error("NULL should give nil")
+	}
+}
+
+type inetRow struct {
+	ClientIP netip.Prefix
+	ProxyIP  *netip.Prefix
+	Host     netip.Addr
+}
+
+type inetModel struct {
+	ClientIP *string
+	ProxyIP  *string
+	Host     string
+}
+
+func TestAutoMapInetPointers(t *testing.T) {
+	ip := netip.MustParsePrefix("10.0.0.1/32")
+	got, err := AutoMapWithTags[inetRow, inetModel](inetRow{ClientIP: ip, Host: netip.MustParseAddr("::1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClientIP == nil || *got.ClientIP != "10.0.0.1" || got.Host != "::1" {
+		t.Fatalf("got %v, %q", got.ClientIP, got.Host)
+	}
+	if got.ProxyIP != nil {
+		t.Fatalf("ProxyIP = %q, want nil for a NULL *netip.Prefix", *got.ProxyIP)
+	}
+
+	got, err = AutoMapWithTags[inetRow, inetModel](inetRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClientIP != nil || got.Host != "" {
+		t.Fatalf("NULL: got %v, %q", got.ClientIP, got.Host)
+	}
+}
@@ -0,0 +1,64 @@
+package sqlcmapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+/////////////////////
+// Nullable wrappers
+/////////////////////
+
+// Many codebases have their own null wrapper, typically
+// `type Nullable[T any] struct { Value T; Valid bool }`. The auto-mapper fills
+// any struct of exactly that shape (two fields named Value and Valid, Valid
+// a bool) the way it fills a *T; RegisterNullableWrapper covers other
+// layouts.
+
+var nullableWrappers sync.Map // reflect.Type -> wrapperLayout
+
+// RegisterNullableWrapper teaches the mapper the layout of the wrapper type W
+// (one instantiation, for generic wrappers), given the names of its value
+// field and its bool validity field. It panics if W has no such fields.
+func RegisterNullableWrapper[W any](valueField, validField string) {
+	t := reflect.TypeOf((*W)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("sqlcmapper: RegisterNullableWrapper: %s is not a struct", t))
+	}
+	value, ok := t.FieldByName(valueField)
+	if !ok || len(value.Index) != 1 || !value.IsExported() {
+		panic(fmt.Sprintf("sqlcmapper: RegisterNullableWrapper: %s has no field %s", t, valueField))
+	}
+	valid, ok := t.FieldByName(validField)
+	if !ok || len(valid.Index) != 1 || !valid.IsExported() || valid.Type.Kind() != reflect.Bool {
+		panic(fmt.Sprintf("sqlcmapper: RegisterNullableWrapper: %s has no bool field %s", t, validField))
+	}
+	nullableWrappers.Store(t, wrapperLayout{value: value.Index[0], valid: valid.Index[0], present: -1})
+	registrations.Add(1)
+}
+
+// nullableLayout returns the wrapper layout of t, registered or recognised
+// by shape.
+func nullableLayout(t reflect.Type) (wrapperLayout, bool) {
+	if l, ok := nullableWrappers.Load(t); ok {
+		return l.(wrapperLayout), true
+	}
+	if t.Kind() != reflect.Struct || t.NumField() != 2 || isPgtype(t) {
+		return wrapperLayout{}, false
+	}
+	value, ok := t.FieldByName("Value")
+	valid, ok2 := t.FieldByName("Valid")
+	if !ok || !ok2 || valid.Type.Kind() != reflect.Bool || !value.IsExported() || !valid.IsExported() {
+		return wrapperLayout{}, false
+	}
+	return wrapperLayout{value: value.Index[0], valid: valid.Index[0], present: -1}, true
+}
+
+func nullableSetter(dbType, fieldType reflect.Type, cfg *config, opts tagOptions) *fieldSetter {
+	layout, ok := nullableLayout(fieldType)
+	if !ok || dbType == fieldType {
+		return nil
+	}
+	return wrapperSetter("nullable", dbType, fieldType, layout, cfg, opts)
+}
@@ -0,0 +1,170 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Numeric helpers
+/////////////////////
+
+var (
+	errNumericNotFinite  = errors.New("sqlcmapper: numeric is NaN or infinite")
+	errNumericFractional = errors.New("sqlcmapper: numeric has a fractional part")
+	errNumericOutOfRange = errors.New("sqlcmapper: numeric out of int64 range")
+)
+
+// PgNumericToDecimalString renders n with exactly scale fractional digits,
+// rounding half away from zero ("10" -> "10.00", "1.005" -> "1.01" at scale
+// 2). NULL yields "".
+func PgNumericToDecimalString(n pgtype.Numeric, scale int) (string, error) {
+	if !n.Valid {
+		return "", nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return "", errNumericNotFinite
+	}
+	if scale < 0 {
+		scale = 0
+	}
+
+	digits := rescaleNumeric(n, scale).String()
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	if scale > 0 {
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if neg {
+		digits = "-" + digits
+	}
+	return digits, nil
+}
+
+// rescaleNumeric returns n as an integer count of 10^-scale units, rounding
+// half away from zero.
+func rescaleNumeric(n pgtype.Numeric, scale int) *big.Int {
+	return rescaleNumericMode(n, scale, RoundHalfUp)
+}
+
+func rescaleNumericMode(n pgtype.Numeric, scale int, mode RoundingMode) *big.Int {
+	v := new(big.Int)
+	if n.Int != nil {
+		v.Set(n.Int)
+	}
+	shift := int64(n.Exp) + int64(scale)
+	ten := big.NewInt(10)
+	if shift >= 0 {
+		return v.Mul(v, new(big.Int).Exp(ten, big.NewInt(shift), nil))
+	}
+
+	div := new(big.Int).Exp(ten, big.NewInt(-shift), nil)
+	neg := v.Sign() < 0
+	v.Abs(v)
+	q, r := new(big.Int).QuoRem(v, div, new(big.Int))
+	switch cmp := r.Lsh(r, 1).Cmp(div); {
+	case cmp > 0, cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1):
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	return q
+}
+
+// PgNumericToInt64Ptr converts an integer-valued numeric, such as the result
+// of SUM over an integer column, to *int64. A fractional part is an error;
+// NULL yields nil.
+func PgNumericToInt64Ptr(n pgtype.Numeric) (*int64, error) {
+	return numericToInt64Ptr(n, false)
+}
+
+// PgNumericToInt64PtrTrunc is PgNumericToInt64Ptr but drops any fractional
+// part, truncating toward zero.
+func PgNumericToInt64PtrTrunc(n pgtype.Numeric) (*int64, error) {
+	return numericToInt64Ptr(n, true)
+}
+
+func numericToInt64Ptr(n pgtype.Numeric, truncate bool) (*int64, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return nil, errNumericNotFinite
+	}
+	v := new(big.Int)
+	if n.Int != nil {
+		v.Set(n.Int)
+	}
+	ten := big.NewInt(10)
+	if n.Exp >= 0 {
+		v.Mul(v, new(big.Int).Exp(ten, big.NewInt(int64(n.Exp)), nil))
+	} else {
+		div := new(big.Int).Exp(ten, big.NewInt(-int64(n.Exp)), nil)
+		var r big.Int
+		v.QuoRem(v, div, &r)
+		if r.Sign() != 0 && !truncate {
+			return nil, errNumericFractional
+		}
+	}
+	if !v.IsInt64() {
+		return nil, errNumericOutOfRange
+	}
+	i := v.Int64()
+	return &i, nil
+}
+
+// RoundingMode picks how a value exactly halfway between two results is
+// rounded.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero: 0.125 -> 0.13, -0.125 -> -0.13.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven (bankers' rounding) rounds halves to the even neighbour:
+	// 0.125 -> 0.12, 0.135 -> 0.14.
+	RoundHalfEven
+)
+
+// Money is an amount in minor units (cents) with its ISO currency code.
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+// PgNumericToCents converts a numeric amount to whole cents, rounding to two
+// decimals with mode. NULL yields nil.
+func PgNumericToCents(n pgtype.Numeric, mode RoundingMode) (*int64, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return nil, errNumericNotFinite
+	}
+	cents := rescaleNumericMode(n, 2, mode)
+	if !cents.IsInt64() {
+		return nil, errNumericOutOfRange
+	}
+	c := cents.Int64()
+	return &c, nil
+}
+
+// MoneyConverter returns a numeric -> Money converter for RegisterConverter or
+// WithConverter, e.g. RegisterConverter(MoneyConverter(RoundHalfEven)). It
+// fills Cents only, leaving Currency to be set from its own column. NULL gives
+// the zero Money.
+func MoneyConverter(mode RoundingMode) func(pgtype.Numeric) (Money, error) {
+	return func(n pgtype.Numeric) (Money, error) {
+		cents, err := PgNumericToCents(n, mode)
+		if err != nil || cents == nil {
+			return Money{}, err
+		}
+		return Money{Cents: *cents}, nil
+	}
+}
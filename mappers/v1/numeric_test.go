@@ -0,0 +1,160 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func numeric(t *testing.T, s string) pgtype.Numeric {
+	t.Helper()
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		t.Fatalf("scan %q: %v", s, err)
+	}
+	return n
+}
+
+func TestPgNumericToDecimalString(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		scale int
+		want  string
+	}{
+		{"10", 2, "10.00"},
+		{"10.000", 2, "10.00"},
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"2.675", 2, "2.68"},
+		{"-1.005", 2, "-1.01"},
+		{"0.5", 0, "1"},
+		{"-0.5", 0, "-1"},
+		{"0.004", 2, "0.00"},
+		{"123.456", 1, "123.5"},
+	} {
+		got, err := PgNumericToDecimalString(numeric(t, tc.in), tc.scale)
+		if err != nil || got != tc.want {
+			t.Errorf("%s at scale %d: got %q, %v; want %q", tc.in, tc.scale, got, err, tc.want)
+		}
+	}
+
+	if got, err := PgNumericToDecimalString(pgtype.Numeric{}, 2); got != "" || err != nil {
+		t.Errorf("NULL: got %q, %v", got, err)
+	}
+	if _, err := PgNumericToDecimalString(pgtype.Numeric{NaN: true, Valid: true}, 2); err == nil {
+		t.Error("NaN should be an error")
+	}
+}
+
+type scaleRow struct{ Amount pgtype.Numeric }
+
+type scaleModel struct {
+	Amount string `db:"Amount,scale=2"`
+}
+
+func TestAutoMapNumericScaleTag(t *testing.T) {
+	got, err := AutoMapWithTags[scaleRow, scaleModel](scaleRow{Amount: numeric(t, "19.995")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != "20.00" {
+		t.Fatalf("Amount = %q, want 20.00", got.Amount)
+	}
+}
+
+func TestPgNumericToCents(t *testing.T) {
+	for _, tc := range []struct {
+		in            string
+		halfUp, banks int64
+	}{
+		{"0.125", 13, 12},
+		{"0.135", 14, 14},
+		{"-0.125", -13, -12},
+		{"-0.135", -14, -14},
+		{"1.005", 101, 100},
+		{"1.015", 102, 102},
+		{"0.1251", 13, 13},
+		{"10", 1000, 1000},
+		{"99.99", 9999, 9999},
+	} {
+		n := numeric(t, tc.in)
+		up, err := PgNumericToCents(n, RoundHalfUp)
+		if err != nil || up == nil || *up != tc.halfUp {
+			t.Errorf("%s half-up: got %v, %v; want %d", tc.in, up, err, tc.halfUp)
+		}
+		even, err := PgNumericToCents(n, RoundHalfEven)
+		if err != nil || even == nil || *even != tc.banks {
+			t.Errorf("%s half-even: got %v, %v; want %d", tc.in, even, err, tc.banks)
+		}
+	}
+
+	if got, err := PgNumericToCents(pgtype.Numeric{}, RoundHalfUp); got != nil || err != nil {
+		t.Errorf("NULL: got %v, %v", got, err)
+	}
+	if _, err := PgNumericToCents(numeric(t, "1000000000000000000000000"), RoundHalfUp); err == nil {
+		t.Error("out of range amounts should be an error")
+	}
+}
+
+type moneyRow struct {
+	Amount   pgtype.Numeric
+	Currency string
+}
+
+type moneyModel struct {
+	Amount   Money
+	Currency string
+}
+
+func TestMoneyConverter(t *testing.T) {
+	row := moneyRow{Amount: numeric(t, "12.345"), Currency: "EUR"}
+	got, err := AutoMapWithTags[moneyRow, moneyModel](row, WithConverter(MoneyConverter(RoundHalfEven)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != (Money{Cents: 1234}) || got.Currency != "EUR" {
+		t.Fatalf("got %+v", got)
+	}
+
+	got, err = AutoMapWithTags[moneyRow, moneyModel](row, WithConverter(MoneyConverter(RoundHalfUp)))
+	if err != nil || got.Amount.Cents != 1235 {
+		t.Fatalf("half-up: got %+v, %v", got, err)
+	}
+
+	got, err = AutoMapWithTags[moneyRow, moneyModel](moneyRow{}, WithConverter(MoneyConverter(RoundHalfUp)))
+	if err != nil || got.Amount != (Money{}) {
+		t.Fatalf("NULL: got %+v, %v", got, err)
+	}
+}
+
+func TestPgNumericToInt64Ptr(t *testing.T) {
+	got, err := PgNumericToInt64Ptr(pgtype.Numeric{Int: bigInt(42), Exp: 0, Valid: true})
+	if err != nil || got == nil || *got != 42 {
+		t.Fatalf("Exp=0: got %v, %v; want 42", show(got), err)
+	}
+	if _, err := PgNumericToInt64Ptr(numeric(t, "42.5")); !errors.Is(err, errNumericFractional) {
+		t.Fatalf("fractional: err = %v, want %v", err, errNumericFractional)
+	}
+	got, err = PgNumericToInt64PtrTrunc(numeric(t, "-42.5"))
+	if err != nil || got == nil || *got != -42 {
+		t.Fatalf("trunc: got %v, %v; want -42", show(got), err)
+	}
+	if got, err := PgNumericToInt64Ptr(pgtype.Numeric{}); got != nil || err != nil {
+		t.Fatalf("NULL: got %v, %v; want nil, nil", show(got), err)
+	}
+}
+
+func TestAutoMapNumericIntTag(t *testing.T) {
+	type row struct{ Total pgtype.Numeric }
+	type model struct {
+		Total *int64 `db:"total,int"`
+	}
+	got, err := AutoMapWithTags[row, model](row{Total: numeric(t, "1200")})
+	if err != nil || got.Total == nil || *got.Total != 1200 {
+		t.Fatalf("got %v, %v; want 1200", show(got.Total), err)
+	}
+	if _, err := AutoMapWithTags[row, model](row{Total: numeric(t, "1.5")}); err == nil {
+		t.Fatal("expected an error for a fractional value")
+	}
+}
@@ -0,0 +1,189 @@
+package sqlcmapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Optional values
+/////////////////////
+
+// Optional holds a tri-state value: absent (Present false), NULL (Present
+// true, Valid false) or a value. Pointers cannot tell the first two apart,
+// which PATCH-style APIs need.
+//
+// The auto-mapper sets Present whenever the field matched a column. When
+// marshalled, an absent Optional is zero for `json:",omitzero"`, NULL becomes
+// null; unmarshalling reverses this.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+	Valid   bool
+}
+
+// Some returns a present, non-NULL Optional holding v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true, Valid: true}
+}
+
+// Null returns a present Optional holding NULL.
+func Null[T any]() Optional[T] {
+	return Optional[T]{Present: true}
+}
+
+// IsZero reports whether the value is absent, so that `json:",omitzero"`
+// drops absent fields.
+func (o Optional[T]) IsZero() bool {
+	return !o.Present
+}
+
+// Ptr returns the value as a pointer, nil when absent or NULL.
+func (o Optional[T]) Ptr() *T {
+	if !o.Valid {
+		return nil
+	}
+	v := o.Value
+	return &v
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	var zero T
+	o.Value, o.Present, o.Valid = zero, true, false
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(b, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+
+func optionalOf[T any](v T, valid bool) Optional[T] {
+	if !valid {
+		return Null[T]()
+	}
+	return Some(v)
+}
+
+func PgTextToOptional(txt pgtype.Text) Optional[string] {
+	return optionalOf(txt.String, txt.Valid)
+}
+
+func PgInt2ToOptional(i pgtype.Int2) Optional[int16] {
+	return optionalOf(i.Int16, i.Valid)
+}
+
+func PgInt4ToOptional(i pgtype.Int4) Optional[int32] {
+	return optionalOf(i.Int32, i.Valid)
+}
+
+func PgInt8ToOptional(i pgtype.Int8) Optional[int64] {
+	return optionalOf(i.Int64, i.Valid)
+}
+
+func PgFloat8ToOptional(f pgtype.Float8) Optional[float64] {
+	return optionalOf(f.Float64, f.Valid)
+}
+
+func PgBoolToOptional(b pgtype.Bool) Optional[bool] {
+	return optionalOf(b.Bool, b.Valid)
+}
+
+func PgUUIDToOptional(id pgtype.UUID) Optional[string] {
+	return optionalOf(PgUUIDToString(id), id.Valid)
+}
+
+// PgTimestamptzToOptional treats infinite timestamps as NULL, like
+// PgTimestamptzToTimePtr.
+func PgTimestamptzToOptional(ts pgtype.Timestamptz) Optional[time.Time] {
+	if t := PgTimestamptzToTimePtr(ts); t != nil {
+		return Some(*t)
+	}
+	return Null[time.Time]()
+}
+
+var optionalPkgPath = reflect.TypeOf(Optional[int]{}).PkgPath()
+
+// optionalValueType returns T for an Optional[T] type.
+func optionalValueType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != optionalPkgPath || !strings.HasPrefix(t.Name(), "Optional[") {
+		return nil, false
+	}
+	return t.Field(0).Type, true
+}
+
+// optionalSetter maps into an Optional[T] field.
+func optionalSetter(dbType, fieldType reflect.Type, cfg *config, opts tagOptions) *fieldSetter {
+	if _, ok := optionalValueType(fieldType); !ok {
+		return nil
+	}
+	return wrapperSetter("optional", dbType, fieldType, wrapperLayout{value: 0, valid: 2, present: 1}, cfg, opts)
+}
+
+// wrapperLayout locates the fields of a value-plus-validity wrapper struct;
+// present is -1 when the wrapper has no presence flag.
+type wrapperLayout struct {
+	value, valid, present int
+}
+
+// wrapperSetter maps into a wrapper field by mapping into *T, which tells
+// NULL from a value for every pgtype the mapper understands, and falls back
+// to T plus the db value's own validity.
+func wrapperSetter(kind string, dbType, fieldType reflect.Type, layout wrapperLayout, cfg *config, opts tagOptions) *fieldSetter {
+	valueType := fieldType.Field(layout.value).Type
+	store := func(field reflect.Value, value reflect.Value) {
+		if layout.present >= 0 {
+			field.Field(layout.present).SetBool(true)
+		}
+		if !value.IsValid() {
+			field.Field(layout.value).Set(reflect.Zero(valueType))
+			field.Field(layout.valid).SetBool(false)
+			return
+		}
+		field.Field(layout.value).Set(value)
+		field.Field(layout.valid).SetBool(true)
+	}
+	if inner := resolveSetter(dbType, reflect.PointerTo(valueType), cfg, opts); inner != nil {
+		return &fieldSetter{kind: kind + " " + inner.kind, set: func(field, dbField reflect.Value) error {
+			ptr := reflect.New(reflect.PointerTo(valueType)).Elem()
+			if err := inner.set(ptr, dbField); err != nil {
+				return err
+			}
+			if ptr.IsNil() {
+				store(field, reflect.Value{})
+			} else {
+				store(field, ptr.Elem())
+			}
+			return nil
+		}}
+	}
+	if inner := resolveSetter(dbType, valueType, cfg, opts); inner != nil {
+		return &fieldSetter{kind: kind + " " + inner.kind, set: func(field, dbField reflect.Value) error {
+			if isNullDBValue(dbField) {
+				store(field, reflect.Value{})
+				return nil
+			}
+			v := reflect.New(valueType).Elem()
+			if err := inner.set(v, dbField); err != nil {
+				return err
+			}
+			store(field, v)
+			return nil
+		}}
+	}
+	return nil
+}
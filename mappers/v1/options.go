@@ -0,0 +1,392 @@
+package sqlcmapper
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/////////////////////
+// Options
+/////////////////////
+
+type Option func(*config)
+
+type config struct {
+	strict         bool
+	lossyConvert   bool
+	requiredFields map[string]bool
+	allowedFields  map[string]bool // nil means every field
+
+	emptyStringAsNil bool
+	strictArrayLen   bool
+	maxSliceLen      int
+	binaryEncoding   BinaryEncoding
+	timeLayout       string
+	dateLayout       string
+	forceUTC         bool
+	trimZeroTime     bool
+	ignoreUnexported bool
+	int8AsString     bool
+	snakeCaseBoth    bool
+	normalizeTag     bool
+	prefixGrouping   bool
+	nameNormalizer   func(string) string
+	fieldMapping     map[string]string // model field name -> column
+	allowMissing     bool
+	jsonTagFallback  bool
+	collectErrors    bool
+	atomic           bool
+
+	errorOnPartialNested bool
+
+	converters []converter
+	ctx        context.Context  // passed to context converters; nil means Background
+	now        func() time.Time // nil means time.Now
+
+	beforeFieldSet func(modelField, dbColumn string, value any)
+	tagHandlers    map[string]TagOptionHandler
+
+	plans sync.Map // typePair -> *structPlan
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{timeLayout: time.RFC3339, dateLayout: "2006-01-02", ignoreUnexported: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// planKey identifies the plans built under a config by its settings, for
+// configs whose setters behave the same whichever config built them: no
+// funcs, per-call converters, field mapping or context. A new config field
+// that changes plans belongs here, or must make planKey report false.
+type planKey struct {
+	pair          typePair
+	registrations uint64
+
+	strict, lossyConvert, emptyStringAsNil, strictArrayLen bool
+	forceUTC, trimZeroTime, ignoreUnexported, int8AsString bool
+	snakeCaseBoth, normalizeTag, prefixGrouping            bool
+	allowMissing, jsonTagFallback, collectErrors           bool
+	errorOnPartialNested                                   bool
+
+	maxSliceLen            int
+	binaryEncoding         BinaryEncoding
+	timeLayout, dateLayout string
+}
+
+func (c *config) planKey(pair typePair) (planKey, bool) {
+	if len(c.converters) > 0 || c.nameNormalizer != nil || c.fieldMapping != nil || c.now != nil ||
+		c.beforeFieldSet != nil || c.tagHandlers != nil || c.ctx != nil {
+		return planKey{}, false
+	}
+	return planKey{
+		pair:                 pair,
+		registrations:        registrations.Load(),
+		strict:               c.strict,
+		lossyConvert:         c.lossyConvert,
+		emptyStringAsNil:     c.emptyStringAsNil,
+		strictArrayLen:       c.strictArrayLen,
+		forceUTC:             c.forceUTC,
+		trimZeroTime:         c.trimZeroTime,
+		ignoreUnexported:     c.ignoreUnexported,
+		int8AsString:         c.int8AsString,
+		snakeCaseBoth:        c.snakeCaseBoth,
+		normalizeTag:         c.normalizeTag,
+		prefixGrouping:       c.prefixGrouping,
+		allowMissing:         c.allowMissing,
+		jsonTagFallback:      c.jsonTagFallback,
+		collectErrors:        c.collectErrors,
+		errorOnPartialNested: c.errorOnPartialNested,
+		maxSliceLen:          c.maxSliceLen,
+		binaryEncoding:       c.binaryEncoding,
+		timeLayout:           c.timeLayout,
+		dateLayout:           c.dateLayout,
+	}, true
+}
+
+func (c *config) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+func (c *config) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// WithLossyConvert allows the plain-field fallback to convert float columns
+// into integer model fields, truncating any fractional part, and to narrow
+// integers that overflow the model type, wrapping as a Go conversion does.
+// Without it an overflowing integer is a conversion error.
+func WithLossyConvert() Option {
+	return func(c *config) {
+		c.lossyConvert = true
+	}
+}
+
+// WithRequiredFields makes the mapper return a MapError when one of the named
+// model fields ends up empty because its column was NULL, or cannot be bound
+// at all because no db field matches it or there is no conversion.
+func WithRequiredFields(names ...string) Option {
+	return func(c *config) {
+		if c.requiredFields == nil {
+			c.requiredFields = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			c.requiredFields[name] = true
+		}
+	}
+}
+
+// WithFieldAllowList restricts mapping to the named model fields; every other
+// field is left untouched, including by WithStrict checks. Useful with
+// AutoMapInto to fill a projection of a full row.
+func WithFieldAllowList(names ...string) Option {
+	return func(c *config) {
+		if c.allowedFields == nil {
+			c.allowedFields = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			c.allowedFields[name] = true
+		}
+	}
+}
+
+// WithEmptyStringAsNil maps a non-NULL empty pgtype.Text to a nil *string, so
+// legacy "" values and NULL are both treated as absent. Plain string fields
+// are unaffected.
+func WithEmptyStringAsNil() Option {
+	return func(c *config) {
+		c.emptyStringAsNil = true
+	}
+}
+
+// WithStrictArrayLen makes mapping into a fixed-size [N]T field fail unless
+// the source array has exactly N elements. By default shorter sources are
+// zero-filled.
+func WithStrictArrayLen() Option {
+	return func(c *config) {
+		c.strictArrayLen = true
+	}
+}
+
+// WithMaxSliceLen rejects slice and array columns longer than n elements with
+// a MapError instead of allocating them. Zero, the default, means no limit.
+func WithMaxSliceLen(n int) Option {
+	return func(c *config) {
+		c.maxSliceLen = n
+	}
+}
+
+// WithBinaryEncoding sets how bytea columns are rendered into string model
+// fields. The default is HexEncoding.
+func WithBinaryEncoding(enc BinaryEncoding) Option {
+	return func(c *config) {
+		c.binaryEncoding = enc
+	}
+}
+
+// WithStrict turns mapping problems that are silently skipped by default,
+// such as unmatched model fields or unknown tag options, into errors.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithTimeLayout sets the layout used when timestamptz columns map into string
+// fields. The default is time.RFC3339. A `db:"col,layout=..."` tag option
+// overrides it for a single field. ModelToStringSliceWith uses it for time
+// values.
+func WithTimeLayout(layout string) Option {
+	return func(c *config) {
+		c.timeLayout = layout
+	}
+}
+
+// WithIgnoreUnexported controls what happens when a column matches a model
+// field that cannot be set, such as an unexported one. By default the field
+// is skipped; passing false returns a MapError instead. WithStrict always
+// reports such fields.
+func WithIgnoreUnexported(ignore bool) Option {
+	return func(c *config) {
+		c.ignoreUnexported = ignore
+	}
+}
+
+// WithInt8AsString maps every bigint column into string and *string fields
+// as a decimal string. Use the `db:"id,asstring"` tag option to do this for
+// single fields only.
+func WithInt8AsString() Option {
+	return func(c *config) {
+		c.int8AsString = true
+	}
+}
+
+// WithSnakeCaseForBoth snake_cases the model-side name as well as the db
+// field name before matching, so untagged PascalCase fields on both sides
+// still line up.
+func WithSnakeCaseForBoth() Option {
+	return func(c *config) {
+		c.snakeCaseBoth = true
+	}
+}
+
+// WithFieldMapping names the column for specific model fields, keyed by Go
+// field name, e.g. {"Total": "sum_amount_cents"}. It takes precedence over
+// db and json tags, though options in the db tag still apply. Repeated uses
+// merge.
+func WithFieldMapping(m map[string]string) Option {
+	return func(c *config) {
+		if c.fieldMapping == nil {
+			c.fieldMapping = make(map[string]string, len(m))
+		}
+		for field, column := range m {
+			c.fieldMapping[field] = column
+		}
+	}
+}
+
+// WithNormalizeTag also tries the snake_case form of a db tag value, so
+// `db:"UserID"` matches a user_id db field. Unlike WithSnakeCaseForBoth it
+// leaves untagged field names alone. Exact tag matches still win.
+func WithNormalizeTag() Option {
+	return func(c *config) {
+		c.normalizeTag = true
+	}
+}
+
+// WithPrefixGrouping fills nested model structs from the prefixed columns
+// of a flat JOIN row: a User field with no user column of its own reads
+// user_id into User.ID and user_name into User.Name. Nested fields group
+// again, so User.Address reads user_address_city. Without this option only
+// fields tagged `db:"user,prefix"` group, and the tag also wins over a
+// direct match. A prefixed column can still feed a top-level field too (say
+// UserID); a *User field is left nil when all of its columns are NULL.
+func WithPrefixGrouping() Option {
+	return func(c *config) {
+		c.prefixGrouping = true
+	}
+}
+
+// WithNameNormalizer applies fn to both db field names and model column names
+// before matching, e.g. to strip a tenant prefix or an "_at" suffix. It is
+// tried after exact (and WithSnakeCaseForBoth) matches fail.
+func WithNameNormalizer(fn func(string) string) Option {
+	return func(c *config) {
+		c.nameNormalizer = fn
+	}
+}
+
+// WithBeforeFieldSet calls fn with each converted value just before it is
+// stored in its model field, including fields of nested structs, e.g. to
+// trace mapping or count NULLs per column. fn cannot change the value.
+func WithBeforeFieldSet(fn func(modelField, dbColumn string, value any)) Option {
+	return func(c *config) {
+		c.beforeFieldSet = fn
+	}
+}
+
+// WithTagOption registers handler for the db tag option name, e.g.
+// `db:"status,upper"` or `db:"code,pad=4"`. A registered option takes over
+// the field's conversion, overriding built-in options of the same name, and
+// counts as known under WithStrict.
+func WithTagOption(name string, handler func(opt string, dbVal any, dst reflect.Value) error) Option {
+	return func(c *config) {
+		if c.tagHandlers == nil {
+			c.tagHandlers = make(map[string]TagOptionHandler)
+		}
+		c.tagHandlers[name] = handler
+	}
+}
+
+// WithForceUTC converts timestamptz values mapped into time.Time and
+// *time.Time fields to UTC.
+func WithForceUTC() Option {
+	return func(c *config) {
+		c.forceUTC = true
+	}
+}
+
+// WithErrorOnPartialNested fails mapping of a nested struct whose model
+// fields match none of the nested db struct's fields, a sign the two types
+// do not belong together. Errors from nested structs then carry the full
+// field path, e.g. "Billing.Street".
+func WithErrorOnPartialNested() Option {
+	return func(c *config) {
+		c.errorOnPartialNested = true
+	}
+}
+
+// WithAtomic makes AutoMapInto map into a copy of dst and store it only when
+// mapping succeeds, so an error never leaves dst half-populated. The cost is
+// one extra copy of the model per call. Other entry points already return a
+// zero model on error.
+func WithAtomic() Option {
+	return func(c *config) {
+		c.atomic = true
+	}
+}
+
+// WithTrimZeroTime treats a non-NULL timestamptz holding Go's zero time
+// (0001-01-01), a placeholder some ORMs write, as NULL: *time.Time fields
+// get nil and string fields "".
+func WithTrimZeroTime() Option {
+	return func(c *config) {
+		c.trimZeroTime = true
+	}
+}
+
+// WithNow replaces time.Now as the reference time for the age tag option,
+// e.g. to get deterministic output in tests.
+func WithNow(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// WithAllowMissingColumns relaxes WithStrict for models that are ahead of the
+// schema: an unmatched model field is only an error when an unmatched db
+// field has a close name (say Emial for Email), which points at a typo
+// rather than a column that doesn't exist yet. Each field is judged on its
+// own, so an extra db column does not make every missing one an error.
+func WithAllowMissingColumns() Option {
+	return func(c *config) {
+		c.allowMissing = true
+	}
+}
+
+// WithDefaultTagFromJSON uses the json tag name for matching when a model
+// field has no db tag. Precedence is db tag, then json tag, then the field
+// name.
+func WithDefaultTagFromJSON() Option {
+	return func(c *config) {
+		c.jsonTagFallback = true
+	}
+}
+
+// WithCollectErrors keeps mapping after a field fails and returns every
+// problem at once as a *MapErrors, which is most useful with WithStrict to
+// see all tag mistakes in one run.
+func WithCollectErrors() Option {
+	return func(c *config) {
+		c.collectErrors = true
+	}
+}
+
+// WithDateLayout sets the layout used when date columns map into string
+// fields. The default is "2006-01-02"; a `db:"col,layout=..."` tag option
+// overrides it for a single field.
+func WithDateLayout(layout string) Option {
+	return func(c *config) {
+		c.dateLayout = layout
+	}
+}
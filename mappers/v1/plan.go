@@ -0,0 +1,1108 @@
+package sqlcmapper
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Mapping plans
+/////////////////////
+
+// A structPlan records, for one db/model type pair, which db field feeds each
+// model field and how it is converted. Plans only depend on types and the
+// config. Configs that planKey can describe share plans across calls, so
+// calling AutoMapWithTags row by row does not rebuild them; any other config
+// builds its plans once and reuses them for the rows of that call.
+
+type fieldSetter struct {
+	kind string
+	set  func(field, dbField reflect.Value) error
+}
+
+type fieldPlan struct {
+	index    int
+	name     string
+	column   string
+	opts     tagOptions
+	dbIndex  []int // nil when no db field matches column
+	dbName   string
+	dbType   reflect.Type
+	settable bool
+	skip     bool         // db:"-"
+	setter   *fieldSetter // nil when there is no conversion path
+
+	// method names the db struct method feeding a `method`-tagged field;
+	// methodErr says why none could be used.
+	method    string
+	methodErr error
+
+	// group holds the plan for a nested model field filled from the
+	// prefix-named columns of the same db struct (the `prefix` tag or
+	// WithPrefixGrouping); prefix is the full column prefix, e.g. "user".
+	group  *structPlan
+	prefix string
+
+	// nearMatch names an unused db field whose name is close to the column
+	// of an unmatched field, marking it as a likely typo.
+	nearMatch string
+}
+
+type structPlan struct {
+	fields []fieldPlan
+	// unusedDBFields lists db fields no model field matched, reported with
+	// unmatched model fields under WithStrict.
+	unusedDBFields []string
+	// duplicates lists columns fed to more than one model field, reported
+	// under WithStrict.
+	duplicates []*MapError
+}
+
+var (
+	sharedPlans sync.Map // planKey -> *structPlan
+	// registrations counts Register* calls that change how plans are built;
+	// it is part of planKey, so shared plans never go stale.
+	registrations atomic.Uint64
+)
+
+func (c *config) planFor(dbType, modelType reflect.Type) *structPlan {
+	pair := typePair{from: dbType, to: modelType}
+	cache, key := &c.plans, any(pair)
+	if pk, ok := c.planKey(pair); ok {
+		cache, key = &sharedPlans, pk
+	}
+	if cached, ok := cache.Load(key); ok {
+		return cached.(*structPlan)
+	}
+	plan := buildPlan(dbType, modelType, c)
+	cached, _ := cache.LoadOrStore(key, plan)
+	return cached.(*structPlan)
+}
+
+func buildPlan(dbType, modelType reflect.Type, cfg *config) *structPlan {
+	used := make(map[string]bool)
+	plan := &structPlan{fields: buildFields(dbType, modelType, cfg, "", used)}
+	plan.duplicates = duplicateColumns(plan.fields)
+	for i := 0; i < dbType.NumField(); i++ {
+		if sf := dbType.Field(i); sf.IsExported() && !sf.Anonymous && !used[sf.Name] {
+			plan.unusedDBFields = append(plan.unusedDBFields, sf.Name)
+		}
+	}
+	markNearMatches(plan.fields, plan.unusedDBFields)
+	return plan
+}
+
+// markNearMatches sets nearMatch on every unmatched field, in prefix groups
+// too, whose column is within a couple of edits of an unused db field. This
+// is what lets WithAllowMissingColumns tell a typo from a column the schema
+// does not have yet.
+func markNearMatches(fields []fieldPlan, unused []string) {
+	for i := range fields {
+		fp := &fields[i]
+		switch {
+		case fp.group != nil:
+			markNearMatches(fp.group.fields, unused)
+		case fp.skip || fp.dbIndex != nil || fp.method != "" || fp.methodErr != nil:
+		default:
+			for _, name := range unused {
+				if similarNames(fp.column, name) {
+					fp.nearMatch = name
+					break
+				}
+			}
+		}
+	}
+}
+
+// similarNames reports whether a and b, ignoring case and underscores, are
+// at most two edits apart, or one for names of three letters or fewer.
+func similarNames(a, b string) bool {
+	norm := func(s string) []rune {
+		return []rune(strings.ToLower(strings.ReplaceAll(s, "_", "")))
+	}
+	x, y := norm(a), norm(b)
+	limit := 2
+	if min(len(x), len(y)) <= 3 {
+		limit = 1
+	}
+	return editDistance(x, y) <= limit
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// buildFields plans each field of modelType against the db fields whose
+// names start with prefix (all of them when prefix is ""), recording every
+// db field it uses in used.
+func buildFields(dbType, modelType reflect.Type, cfg *config, prefix string, used map[string]bool) []fieldPlan {
+	index := dbFieldIndex(dbType)
+	fields := make([]fieldPlan, 0, modelType.NumField())
+	for i := 0; i < modelType.NumField(); i++ {
+		sf := modelType.Field(i)
+		column, opts := cfg.columnFor(sf)
+		fp := fieldPlan{
+			index:    i,
+			name:     sf.Name,
+			column:   prefixedColumn(prefix, column),
+			opts:     opts,
+			settable: sf.IsExported(),
+			skip:     column == "-",
+		}
+		if fp.skip {
+			fields = append(fields, fp)
+			continue
+		}
+		if opts.has("method") {
+			if m, err := findDBMethod(dbType, fp.column); err != nil {
+				fp.methodErr = err
+			} else {
+				fp.method = m.Name
+				fp.dbName = m.Name + "()"
+				fp.dbType = m.Type.Out(0)
+				fp.setter = resolveSetter(fp.dbType, sf.Type, cfg, opts)
+			}
+			fields = append(fields, fp)
+			continue
+		}
+		idx, ok := cfg.lookupColumn(index, prefix, column)
+		if !ok && cfg.normalizeTag && hasDBTagName(sf) {
+			idx, ok = index[prefixedColumn(prefix, toSnakeCase(column))]
+		}
+		if group, grouped := cfg.groupPrefix(index, sf, column, opts, prefix, ok); grouped {
+			fp.prefix = group
+			fp.dbName = group + "_*"
+			fp.group = &structPlan{fields: buildFields(dbType, derefType(sf.Type), cfg, group, used)}
+			fields = append(fields, fp)
+			continue
+		}
+		if ok {
+			dbSF := dbType.FieldByIndex(idx)
+			fp.dbIndex = idx
+			fp.dbName = dbSF.Name
+			fp.dbType = dbSF.Type
+			used[dbSF.Name] = true
+			fp.setter = resolveSetter(dbSF.Type, sf.Type, cfg, opts)
+		}
+		fields = append(fields, fp)
+	}
+	return fields
+}
+
+// prefixedColumn names column inside a prefix group: "user" and "ID" give
+// "user_id". Outside a group the column is used as is.
+func prefixedColumn(prefix, column string) string {
+	if prefix == "" {
+		return column
+	}
+	return prefix + "_" + toSnakeCase(column)
+}
+
+// lookupColumn finds the db field for column within prefix, trying the
+// column as written before its snake_case form.
+func (c *config) lookupColumn(index map[string][]int, prefix, column string) ([]int, bool) {
+	if prefix == "" {
+		return c.matchDBField(index, column)
+	}
+	if idx, ok := c.matchDBField(index, prefix+"_"+column); ok {
+		return idx, true
+	}
+	return c.matchDBField(index, prefixedColumn(prefix, column))
+}
+
+// groupPrefix decides whether a nested struct field is filled from prefixed
+// columns and returns the full prefix. A `prefix` tag always groups;
+// WithPrefixGrouping also groups fields whose column matched no db field,
+// using the snake_case column as prefix. Either way at least one db field
+// must carry the prefix, otherwise the field is treated as unmatched.
+func (c *config) groupPrefix(index map[string][]int, sf reflect.StructField, column string, opts tagOptions, prefix string, matched bool) (string, bool) {
+	if !isNestedStructOrPtr(sf.Type) {
+		return "", false
+	}
+	if !opts.has("prefix") && (!c.prefixGrouping || matched) {
+		return "", false
+	}
+	group := prefixedColumn(prefix, column)
+	if prefix == "" {
+		group = toSnakeCase(column)
+	}
+	for name := range index {
+		if strings.HasPrefix(name, group+"_") {
+			return group, true
+		}
+	}
+	return "", false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// findDBMethod finds the method a `method`-tagged field reads: one named like
+// the column, directly or after snake_casing, that takes no arguments and
+// returns a single value. Pointer-receiver methods are included.
+func findDBMethod(dbType reflect.Type, column string) (reflect.Method, error) {
+	pt := reflect.PointerTo(dbType)
+	for i := 0; i < pt.NumMethod(); i++ {
+		m := pt.Method(i)
+		if m.Name != column && toSnakeCase(m.Name) != column {
+			continue
+		}
+		if m.Type.NumIn() != 1 || m.Type.NumOut() != 1 {
+			return reflect.Method{}, fmt.Errorf("method %s.%s must take no arguments and return one value", dbType, m.Name)
+		}
+		return m, nil
+	}
+	return reflect.Method{}, fmt.Errorf("no method on %s for column %q", dbType, column)
+}
+
+// callDBMethod calls the named method on dbVal, copying it first when it is
+// not addressable so pointer-receiver methods work too.
+func callDBMethod(dbVal reflect.Value, name string) reflect.Value {
+	if !dbVal.CanAddr() {
+		p := reflect.New(dbVal.Type())
+		p.Elem().Set(dbVal)
+		dbVal = p.Elem()
+	}
+	return dbVal.Addr().MethodByName(name).Call(nil)[0]
+}
+
+// duplicateColumns finds model fields that read the same db field, or the
+// same column name when unmatched, in column order of first appearance.
+func duplicateColumns(fields []fieldPlan) []*MapError {
+	var order []string
+	byColumn := make(map[string][]fieldPlan)
+	for _, fp := range fields {
+		if fp.skip {
+			continue
+		}
+		key := fp.column
+		if fp.dbIndex != nil {
+			key = fp.dbName
+		}
+		if _, ok := byColumn[key]; !ok {
+			order = append(order, key)
+		}
+		byColumn[key] = append(byColumn[key], fp)
+	}
+	var out []*MapError
+	for _, key := range order {
+		fps := byColumn[key]
+		if len(fps) < 2 {
+			continue
+		}
+		names := make([]string, len(fps))
+		for i, fp := range fps {
+			names[i] = fp.name
+		}
+		out = append(out, &MapError{Field: strings.Join(names, ", "), Column: fps[0].column, Reason: "column is mapped by more than one field"})
+	}
+	return out
+}
+
+func hasDBTagName(sf reflect.StructField) bool {
+	name, _ := parseDBTag(sf.Tag.Get("db"))
+	return name != ""
+}
+
+// columnFor returns the column name a model field is matched against and its
+// tag options. Precedence: WithFieldMapping, db tag, json tag (with
+// WithDefaultTagFromJSON), then the field name, which also matches
+// snake_case db field names. Options always come from the db tag.
+func (c *config) columnFor(sf reflect.StructField) (string, tagOptions) {
+	column, opts := parseDBTag(sf.Tag.Get("db"))
+	if mapped, ok := c.fieldMapping[sf.Name]; ok {
+		return mapped, opts
+	}
+	if column == "" && c.jsonTagFallback {
+		if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "-" {
+			column = name
+		}
+	}
+	if column == "" {
+		column = sf.Name
+	}
+	return column, opts
+}
+
+// resolveSetter picks the conversion from dbType into fieldType, or returns
+// nil when there is none. Value-level helpers are probed with zero (NULL)
+// values, which exercises the same type checks without needing real data.
+func resolveSetter(dbType, fieldType reflect.Type, cfg *config, opts tagOptions) *fieldSetter {
+	if s := customTagSetter(opts, cfg); s != nil {
+		return s
+	}
+	if len(opts) > 0 {
+		if ok, err := applyTagOptions(reflect.New(fieldType).Elem(), reflect.Zero(dbType), opts, cfg); ok || err != nil {
+			return &fieldSetter{kind: "tag option", set: func(field, dbField reflect.Value) error {
+				_, err := applyTagOptions(field, dbField, opts, cfg)
+				return err
+			}}
+		}
+	}
+
+	if chain := cfg.findConverterChain(dbType, fieldType); chain != nil {
+		return &fieldSetter{kind: "converter", set: func(field, dbField reflect.Value) error {
+			converted, err := applyConverterChain(cfg.context(), chain, dbField)
+			if err != nil {
+				return err
+			}
+			field.Set(converted)
+			return nil
+		}}
+	}
+
+	if s := optionalSetter(dbType, fieldType, cfg, opts); s != nil {
+		return s
+	}
+	if s := nullableSetter(dbType, fieldType, cfg, opts); s != nil {
+		return s
+	}
+
+	if s := pgtypeSetter(dbType, fieldType, cfg); s != nil {
+		return s
+	}
+
+	s := plainSetter(dbType, fieldType, cfg)
+	// bytea and json sources are []byte too, but their length counts bytes,
+	// not elements.
+	if s == nil || cfg.maxSliceLen <= 0 || (fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array) || isBytesType(dbType) {
+		return s
+	}
+	set := s.set
+	s.set = func(field, dbField reflect.Value) error {
+		if n, ok := sourceLen(dbField); ok && n > cfg.maxSliceLen {
+			return fmt.Errorf("source length %d exceeds limit %d", n, cfg.maxSliceLen)
+		}
+		return set(field, dbField)
+	}
+	return s
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	timePtrType = reflect.TypeOf((*time.Time)(nil))
+	urlPtrType  = reflect.TypeOf((*url.URL)(nil))
+)
+
+func isPtrTo(t reflect.Type, k reflect.Kind) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == k
+}
+
+// pgtypeSetter handles the pgtype scalar wrappers.
+func pgtypeSetter(dbType, fieldType reflect.Type, cfg *config) *fieldSetter {
+	var set func(field, dbField reflect.Value) error
+	switch reflect.Zero(dbType).Interface().(type) {
+	case pgtype.UUID:
+		switch {
+		case fieldType.Kind() == reflect.String:
+			set = func(field, dbField reflect.Value) error {
+				field.SetString(PgUUIDToString(dbField.Interface().(pgtype.UUID)))
+				return nil
+			}
+		case isBytesType(fieldType):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgUUIDToBytes(dbField.Interface().(pgtype.UUID))).Convert(field.Type()))
+				return nil
+			}
+		case fieldType.Kind() == reflect.Array && fieldType.Len() == 16 && fieldType.Elem().Kind() == reflect.Uint8:
+			// [16]byte, or a named type over it such as uuid.UUID.
+			set = func(field, dbField reflect.Value) error {
+				id := dbField.Interface().(pgtype.UUID)
+				if !id.Valid {
+					field.Set(reflect.Zero(field.Type()))
+					return nil
+				}
+				field.Set(reflect.ValueOf(id.Bytes).Convert(field.Type()))
+				return nil
+			}
+		}
+	case pgtype.Text:
+		check := enumCheck(fieldType, cfg)
+		switch {
+		case fieldType == urlPtrType:
+			// Malformed URLs only fail under WithStrict; otherwise they map
+			// to nil.
+			set = func(field, dbField reflect.Value) error {
+				u, err := PgTextToURL(dbField.Interface().(pgtype.Text))
+				if err != nil && cfg.strict {
+					return err
+				}
+				field.Set(reflect.ValueOf(u))
+				return nil
+			}
+		case isPtrTo(fieldType, reflect.String):
+			set = func(field, dbField reflect.Value) error {
+				txt := dbField.Interface().(pgtype.Text)
+				if cfg.emptyStringAsNil && txt.String == "" {
+					field.Set(reflect.Zero(field.Type()))
+					return nil
+				}
+				if check != nil && txt.Valid {
+					if err := check(txt.String); err != nil {
+						return err
+					}
+				}
+				_, err := setStringOrPtr(field, txt.String, !txt.Valid)
+				return err
+			}
+		case fieldType.Kind() == reflect.String:
+			set = func(field, dbField reflect.Value) error {
+				txt := dbField.Interface().(pgtype.Text)
+				if check != nil && txt.Valid {
+					if err := check(txt.String); err != nil {
+						return err
+					}
+				}
+				field.SetString(txt.String)
+				return nil
+			}
+		}
+	case pgtype.Float8:
+		switch {
+		case isPtrTo(fieldType, reflect.Float64):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgFloat8ToFloat64Ptr(dbField.Interface().(pgtype.Float8))))
+				return nil
+			}
+		case fieldType.Kind() == reflect.Float64:
+			// NULL maps to 0, like Int4 into int.
+			set = func(field, dbField reflect.Value) error {
+				f := dbField.Interface().(pgtype.Float8)
+				v := 0.0
+				if f.Valid {
+					v = f.Float64
+				}
+				field.SetFloat(v)
+				return nil
+			}
+		case isStringTarget(fieldType):
+			set = func(field, dbField reflect.Value) error {
+				str, ok := PgFloat8ToDecimalString(dbField.Interface().(pgtype.Float8), -1)
+				_, err := setStringOrPtr(field, str, !ok)
+				return err
+			}
+		}
+	case pgtype.Int2:
+		if isPtrTo(fieldType, reflect.Int16) {
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgInt2ToInt16Ptr(dbField.Interface().(pgtype.Int2))))
+				return nil
+			}
+		}
+	case pgtype.Int4:
+		switch {
+		case isPtrTo(fieldType, reflect.Int32):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgInt4ToInt32Ptr(dbField.Interface().(pgtype.Int4))))
+				return nil
+			}
+		case isPtrTo(fieldType, reflect.Int):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgInt4ToIntPtr(dbField.Interface().(pgtype.Int4))))
+				return nil
+			}
+		case fieldType.Kind() == reflect.Int:
+			set = func(field, dbField reflect.Value) error {
+				field.SetInt(int64(PgInt4ToInt(dbField.Interface().(pgtype.Int4), 0)))
+				return nil
+			}
+		}
+	case pgtype.Int8:
+		switch {
+		case isPtrTo(fieldType, reflect.Int64):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgInt8ToInt64Ptr(dbField.Interface().(pgtype.Int8))))
+				return nil
+			}
+		case cfg.int8AsString && isStringTarget(fieldType):
+			set = func(field, dbField reflect.Value) error {
+				i := dbField.Interface().(pgtype.Int8)
+				_, err := setStringOrPtr(field, strconv.FormatInt(i.Int64, 10), !i.Valid)
+				return err
+			}
+		}
+	case pgtype.Bool:
+		switch {
+		case isPtrTo(fieldType, reflect.Bool):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgBoolToBoolPtr(dbField.Interface().(pgtype.Bool))))
+				return nil
+			}
+		case fieldType.Kind() == reflect.Bool:
+			// NULL maps to false.
+			set = func(field, dbField reflect.Value) error {
+				b := dbField.Interface().(pgtype.Bool)
+				field.SetBool(b.Valid && b.Bool)
+				return nil
+			}
+		}
+	case pgtype.Date:
+		switch {
+		case isStringTarget(fieldType):
+			set = func(field, dbField reflect.Value) error {
+				d := dbField.Interface().(pgtype.Date)
+				_, err := setStringOrPtr(field, formatDate(d, cfg.dateLayout), PgDateToTimePtr(d) == nil)
+				return err
+			}
+		case fieldType == timeType:
+			set = func(field, dbField reflect.Value) error {
+				if t := PgDateToTimePtr(dbField.Interface().(pgtype.Date)); t != nil {
+					field.Set(reflect.ValueOf(*t))
+				} else {
+					field.Set(reflect.Zero(field.Type()))
+				}
+				return nil
+			}
+		case fieldType == timePtrType:
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgDateToTimePtr(dbField.Interface().(pgtype.Date))))
+				return nil
+			}
+		}
+	case pgtype.Time:
+		switch {
+		case isPtrTo(fieldType, reflect.String):
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(PgTimeToStringPtr(dbField.Interface().(pgtype.Time))))
+				return nil
+			}
+		case fieldType.Kind() == reflect.String:
+			set = func(field, dbField reflect.Value) error {
+				field.SetString(PgTimeToString(dbField.Interface().(pgtype.Time)))
+				return nil
+			}
+		}
+	case pgtype.Timestamptz:
+		switch {
+		case fieldType.Kind() == reflect.String:
+			set = func(field, dbField reflect.Value) error {
+				field.SetString(PgTimestamptzToStringLayout(cfg.timestamptz(dbField), cfg.timeLayout))
+				return nil
+			}
+		case fieldType == timeType:
+			set = func(field, dbField reflect.Value) error {
+				t := PgTimestamptzToTime(cfg.timestamptz(dbField), time.Time{})
+				if cfg.forceUTC && !t.IsZero() {
+					t = t.UTC()
+				}
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		case fieldType == timePtrType:
+			toPtr := PgTimestamptzToTimePtr
+			if cfg.forceUTC {
+				toPtr = PgTimestamptzToTimePtrUTC
+			}
+			set = func(field, dbField reflect.Value) error {
+				field.Set(reflect.ValueOf(toPtr(cfg.timestamptz(dbField))))
+				return nil
+			}
+		}
+	}
+	if _, _, ok := pgIntValue(reflect.Zero(dbType)); ok && set == nil {
+		switch {
+		case isUnsignedTarget(fieldType):
+			set = func(field, dbField reflect.Value) error {
+				v, valid, _ := pgIntValue(dbField)
+				return setUintOrPtr(field, v, !valid, cfg)
+			}
+		case isSignedIntTarget(fieldType):
+			// Any other signed width, e.g. Int8 into int64 or Int4 into *int64.
+			set = func(field, dbField reflect.Value) error {
+				v, valid, _ := pgIntValue(dbField)
+				target := field.Type()
+				if target.Kind() == reflect.Ptr {
+					target = target.Elem()
+				}
+				if reflect.Zero(target).OverflowInt(v) {
+					return fmt.Errorf("value %d overflows %s", v, target)
+				}
+				_, err := setIntOrPtr(field, v, !valid)
+				return err
+			}
+		}
+	}
+	if set == nil {
+		return nil
+	}
+	return &fieldSetter{kind: "pgtype", set: set}
+}
+
+// timestamptz reads a Timestamptz db value, treating a valid zero time as
+// NULL under WithTrimZeroTime.
+func (c *config) timestamptz(v reflect.Value) pgtype.Timestamptz {
+	ts := v.Interface().(pgtype.Timestamptz)
+	if c.trimZeroTime && ts.Valid && ts.InfinityModifier == pgtype.Finite && ts.Time.IsZero() {
+		return pgtype.Timestamptz{}
+	}
+	return ts
+}
+
+// pgIntValue reads an Int2, Int4 or Int8 db value.
+func pgIntValue(v reflect.Value) (n int64, valid, ok bool) {
+	switch x := v.Interface().(type) {
+	case pgtype.Int2:
+		return int64(x.Int16), x.Valid, true
+	case pgtype.Int4:
+		return int64(x.Int32), x.Valid, true
+	case pgtype.Int8:
+		return x.Int64, x.Valid, true
+	}
+	return 0, false, false
+}
+
+func isUnsignedKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func isSignedIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isSignedIntTarget(t reflect.Type) bool {
+	return isSignedIntKind(t.Kind()) || (t.Kind() == reflect.Ptr && isSignedIntKind(t.Elem().Kind()))
+}
+
+func isUnsignedTarget(t reflect.Type) bool {
+	return isUnsignedKind(t.Kind()) || (t.Kind() == reflect.Ptr && isUnsignedKind(t.Elem().Kind()))
+}
+
+// setUintOrPtr stores n into an unsigned field or pointer to one. Values the
+// target cannot hold (negative, or too large) are errors under WithStrict and
+// are otherwise clamped to 0 or the type's maximum.
+func setUintOrPtr(field reflect.Value, n int64, null bool, cfg *config) error {
+	target := field
+	if field.Kind() == reflect.Ptr {
+		if null {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		target = reflect.New(field.Type().Elem()).Elem()
+	}
+	var u uint64
+	if n > 0 {
+		u = uint64(n)
+	}
+	switch {
+	case n < 0:
+		if cfg.strict {
+			return errNegativeUnsigned
+		}
+	case target.OverflowUint(u):
+		if cfg.strict {
+			return fmt.Errorf("value %d overflows %s", n, target.Type())
+		}
+		u = 1<<(target.Type().Bits()) - 1
+	}
+	target.SetUint(u)
+	if field.Kind() == reflect.Ptr {
+		field.Set(target.Addr())
+	}
+	return nil
+}
+
+// plainSetter handles everything that isn't a pgtype scalar: arrays, nested
+// structs, slices of structs and plain Go values.
+func plainSetter(dbType, fieldType reflect.Type, cfg *config) *fieldSetter {
+	switch {
+	case dbType == fieldType:
+		return &fieldSetter{kind: "assign", set: func(field, dbField reflect.Value) error {
+			field.Set(dbField)
+			return nil
+		}}
+	case setNetmaskPartsField(reflect.New(fieldType).Elem(), reflect.Zero(dbType)):
+		return &fieldSetter{kind: "netmask", set: func(field, dbField reflect.Value) error {
+			setNetmaskPartsField(field, dbField)
+			return nil
+		}}
+	case fieldType.Kind() == reflect.Slice && isPointTarget(fieldType.Elem()) && isPgVertexType(dbType):
+		return &fieldSetter{kind: "points", set: func(field, dbField reflect.Value) error {
+			setPointsField(field, dbField)
+			return nil
+		}}
+	case dbType == pgPointType && (isPointTarget(fieldType) || (fieldType.Kind() == reflect.Ptr && isPointTarget(fieldType.Elem()))):
+		return &fieldSetter{kind: "point", set: func(field, dbField reflect.Value) error {
+			setPointField(field, dbField)
+			return nil
+		}}
+	case fieldType.Kind() == reflect.Slice && setPgArrayField(reflect.New(fieldType).Elem(), reflect.Zero(dbType).Interface()):
+		return &fieldSetter{kind: "pg array", set: func(field, dbField reflect.Value) error {
+			setPgArrayField(field, dbField.Interface())
+			return nil
+		}}
+	case fieldType.Kind() == reflect.Array && supportsFixedArray(dbType, fieldType):
+		return &fieldSetter{kind: "fixed array", set: func(field, dbField reflect.Value) error {
+			return setFixedArrayField(field, dbField, cfg)
+		}}
+	case isNestedStruct(fieldType) && isNestedStructOrPtr(dbType):
+		// A nil *Nested db field, e.g. from an optional join, leaves the
+		// model struct zero.
+		return &fieldSetter{kind: "nested struct", set: func(field, dbField reflect.Value) error {
+			if dbField.Kind() == reflect.Ptr && dbField.IsNil() {
+				field.Set(reflect.Zero(field.Type()))
+				return nil
+			}
+			if err := cfg.checkNestedMatch(dbField.Type(), field.Type()); err != nil {
+				return err
+			}
+			mapped, err := autoMapWithTagsInterface(dbField.Interface(), field.Type(), cfg, false)
+			if err != nil {
+				return err
+			}
+			field.Set(mapped)
+			return nil
+		}}
+	case fieldType.Kind() == reflect.Ptr && isNestedStruct(fieldType.Elem()) && isNestedStructOrPtr(dbType) && !dbType.AssignableTo(fieldType.Elem()) && dbType != fieldType:
+		return &fieldSetter{kind: "nested struct pointer", set: func(field, dbField reflect.Value) error {
+			if dbField.Kind() == reflect.Ptr && dbField.IsNil() {
+				field.Set(reflect.Zero(field.Type()))
+				return nil
+			}
+			if err := cfg.checkNestedMatch(dbField.Type(), field.Type().Elem()); err != nil {
+				return err
+			}
+			mapped, err := autoMapWithTagsInterface(dbField.Interface(), field.Type().Elem(), cfg, false)
+			if err != nil {
+				return err
+			}
+			p := reflect.New(field.Type().Elem())
+			p.Elem().Set(mapped)
+			field.Set(p)
+			return nil
+		}}
+	case dbType == hardwareAddrType && isStringTarget(fieldType):
+		return &fieldSetter{kind: "macaddr", set: func(field, dbField reflect.Value) error {
+			mac := dbField.Interface().(net.HardwareAddr)
+			_, err := setStringOrPtr(field, PgMacaddrToString(mac), len(mac) == 0)
+			return err
+		}}
+	case isInetType(dbType) && isStringTarget(fieldType):
+		return &fieldSetter{kind: "inet", set: setInetStringField}
+	case isBytesType(dbType) && isJSONTarget(fieldType):
+		return &fieldSetter{kind: "json", set: func(field, dbField reflect.Value) error {
+			return setJSONField(field, dbField.Bytes())
+		}}
+	case dbType == byteSliceType && isStringTarget(fieldType):
+		return &fieldSetter{kind: "bytea", set: func(field, dbField reflect.Value) error {
+			setByteaStringField(field, dbField.Bytes(), cfg)
+			return nil
+		}}
+	case dbType.AssignableTo(fieldType):
+		return &fieldSetter{kind: "assign", set: func(field, dbField reflect.Value) error {
+			field.Set(dbField)
+			return nil
+		}}
+	case fieldType.Kind() == reflect.Slice && !isBytesType(dbType) && sliceSetter(dbType, fieldType, cfg) != nil:
+		return sliceSetter(dbType, fieldType, cfg)
+	case fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Slice && !isBytesType(dbType):
+		return slicePtrSetter(dbType, fieldType, cfg)
+	case fieldType.Kind() == reflect.Ptr && dbType.AssignableTo(fieldType.Elem()):
+		// e.g. a model that keeps *pgtype.Numeric for a pgtype.Numeric column.
+		return &fieldSetter{kind: "address", set: func(field, dbField reflect.Value) error {
+			p := reflect.New(field.Type().Elem())
+			p.Elem().Set(dbField)
+			field.Set(p)
+			return nil
+		}}
+	case canConvertKind(dbType, fieldType, cfg):
+		return &fieldSetter{kind: "convert", set: func(field, dbField reflect.Value) error {
+			if !cfg.lossyConvert {
+				if err := checkIntOverflow(dbField, field.Type()); err != nil {
+					return err
+				}
+			}
+			field.Set(dbField.Convert(field.Type()))
+			return nil
+		}}
+	}
+	return nil
+}
+
+// checkIntOverflow reports an integer v that does not fit the integer type
+// target, which a plain Convert would silently wrap.
+func checkIntOverflow(v reflect.Value, target reflect.Type) error {
+	var fits bool
+	switch {
+	case isSignedIntKind(v.Kind()) && isSignedIntKind(target.Kind()):
+		fits = !reflect.Zero(target).OverflowInt(v.Int())
+	case isSignedIntKind(v.Kind()) && isUnsignedKind(target.Kind()):
+		fits = v.Int() >= 0 && !reflect.Zero(target).OverflowUint(uint64(v.Int()))
+	case isUnsignedKind(v.Kind()) && isSignedIntKind(target.Kind()):
+		fits = v.Uint() <= math.MaxInt64 && !reflect.Zero(target).OverflowInt(int64(v.Uint()))
+	case isUnsignedKind(v.Kind()) && isUnsignedKind(target.Kind()):
+		fits = !reflect.Zero(target).OverflowUint(v.Uint())
+	default:
+		return nil
+	}
+	if !fits {
+		return fmt.Errorf("value %v overflows %s", v, target)
+	}
+	return nil
+}
+
+// isPgtype reports whether t comes from the pgtype package. Those wrappers
+// are leaf values: recursing into their internals would produce garbage.
+func isPgtype(t reflect.Type) bool {
+	return t.PkgPath() == pgtypePkgPath
+}
+
+// sliceSetter maps a plain slice or a pgtype.Array element by element, using
+// the same conversion dispatch as top-level fields: pgtype scalars are
+// unwrapped, structs are recursed into, and so on.
+func sliceSetter(dbType, fieldType reflect.Type, cfg *config) *fieldSetter {
+	elems := func(v reflect.Value) reflect.Value { return v }
+	srcElem := dbType
+	switch {
+	case dbType.Kind() == reflect.Slice:
+		srcElem = dbType.Elem()
+	case isPgtype(dbType) && dbType.Kind() == reflect.Struct:
+		sf, ok := dbType.FieldByName("Elements")
+		if !ok || sf.Type.Kind() != reflect.Slice {
+			return nil
+		}
+		srcElem = sf.Type.Elem()
+		elems = func(v reflect.Value) reflect.Value {
+			if isNullDBValue(v) {
+				return reflect.Value{}
+			}
+			return v.FieldByIndex(sf.Index)
+		}
+	default:
+		return nil
+	}
+
+	elemSetter := resolveSetter(srcElem, fieldType.Elem(), cfg, nil)
+	if elemSetter == nil {
+		return nil
+	}
+	return &fieldSetter{kind: "slice of " + elemSetter.kind, set: func(field, dbField reflect.Value) error {
+		src := elems(dbField)
+		if !src.IsValid() || (src.Kind() == reflect.Slice && src.IsNil()) {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		mapped := reflect.MakeSlice(field.Type(), src.Len(), src.Len())
+		for j := 0; j < src.Len(); j++ {
+			if err := elemSetter.set(mapped.Index(j), src.Index(j)); err != nil {
+				return fmt.Errorf("element %d: %w", j, err)
+			}
+		}
+		field.Set(mapped)
+		return nil
+	}}
+}
+
+// slicePtrSetter fills a pointer to a slice, typically a named collection
+// such as *IDs, through the setter for the slice itself. A NULL or nil
+// source leaves the pointer nil.
+func slicePtrSetter(dbType, fieldType reflect.Type, cfg *config) *fieldSetter {
+	inner := resolveSetter(dbType, fieldType.Elem(), cfg, nil)
+	if inner == nil {
+		return nil
+	}
+	return &fieldSetter{kind: "pointer to " + inner.kind, set: func(field, dbField reflect.Value) error {
+		if isNullDBValue(dbField) || (dbField.Kind() == reflect.Slice && dbField.IsNil()) {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		p := reflect.New(field.Type().Elem())
+		if err := inner.set(p.Elem(), dbField); err != nil {
+			return err
+		}
+		field.Set(p)
+		return nil
+	}}
+}
+
+// checkNestedMatch implements WithErrorOnPartialNested: a nested model with
+// fields to fill, none of which found a column, most likely points at the
+// wrong db type.
+func (c *config) checkNestedMatch(dbType, modelType reflect.Type) error {
+	if !c.errorOnPartialNested {
+		return nil
+	}
+	if dbType.Kind() == reflect.Ptr {
+		dbType = dbType.Elem()
+	}
+	candidates := 0
+	for _, fp := range c.planFor(dbType, modelType).fields {
+		if fp.skip || !fp.settable {
+			continue
+		}
+		if fp.dbIndex != nil || fp.method != "" {
+			return nil
+		}
+		candidates++
+	}
+	if candidates == 0 {
+		return nil
+	}
+	return fmt.Errorf("no field of %s matches a field of %s", modelType, dbType)
+}
+
+// isNestedStruct reports whether t is a struct the mapper recurses into,
+// rather than a value type like time.Time or a pgtype wrapper.
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !isPgtype(t)
+}
+
+func isNestedStructOrPtr(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return isNestedStruct(t)
+}
+
+/////////////////////
+// ExplainMapping
+/////////////////////
+
+// FieldMapping describes how one model field would be populated. Kind is the
+// conversion used, or "unmatched" when no db field has the column name,
+// "skipped" for db:"-", "unsettable" for unexported model fields,
+// "unsupported" when the types have no conversion and "prefix group" for a
+// nested struct filled from prefixed columns, whose fields follow it.
+type FieldMapping struct {
+	Field   string
+	Column  string
+	DBField string
+	Kind    string
+}
+
+type MappingPlan struct {
+	DB     reflect.Type
+	Model  reflect.Type
+	Fields []FieldMapping
+}
+
+// ExplainMapping reports how AutoMapWithTags would map DB into Model with
+// the given options, without needing any data. It uses the same plan as the
+// mapper itself.
+func ExplainMapping[DB any, Model any](opts ...Option) (MappingPlan, error) {
+	dbType := reflect.TypeOf((*DB)(nil)).Elem()
+	if dbType.Kind() == reflect.Ptr {
+		dbType = dbType.Elem()
+	}
+	modelType := reflect.TypeOf((*Model)(nil)).Elem()
+	if dbType.Kind() != reflect.Struct || modelType.Kind() != reflect.Struct {
+		return MappingPlan{}, fmt.Errorf("sqlcmapper: ExplainMapping needs struct types, got %s and %s", dbType, modelType)
+	}
+
+	plan := newConfig(opts).planFor(dbType, modelType)
+	out := MappingPlan{DB: dbType, Model: modelType, Fields: make([]FieldMapping, 0, len(plan.fields))}
+	out.Fields = explainFields(out.Fields, plan, "")
+	return out, nil
+}
+
+// explainFields appends plan's fields to out, following prefix groups with
+// their fields named by path, e.g. "User.ID".
+func explainFields(out []FieldMapping, plan *structPlan, parent string) []FieldMapping {
+	for _, fp := range plan.fields {
+		fm := FieldMapping{Field: parent + fp.name, Column: fp.column, DBField: fp.dbName}
+		switch {
+		case fp.skip:
+			fm.Kind = "skipped"
+		case fp.group != nil:
+			fm.Kind = "prefix group"
+		case fp.methodErr != nil:
+			fm.Kind = "unsupported"
+		case fp.dbIndex == nil && fp.method == "":
+			fm.Kind = "unmatched"
+		case !fp.settable:
+			fm.Kind = "unsettable"
+		case fp.setter == nil:
+			fm.Kind = "unsupported"
+		default:
+			fm.Kind = fp.setter.kind
+		}
+		out = append(out, fm)
+		if fp.group != nil {
+			out = explainFields(out, fp.group, parent+fp.name+".")
+		}
+	}
+	return out
+}
+
+// ValidateMappable checks, without any data, that every model field matched
+// to a db field has a conversion path from it under the given options. Call
+// it from a test or init to catch unsupported target types before the
+// mapper silently skips them. Unmatched fields are not reported; the error
+// is a *MapError, or *MapErrors when several fields fail.
+func ValidateMappable[DB any, Model any](opts ...Option) error {
+	dbType := reflect.TypeOf((*DB)(nil)).Elem()
+	if dbType.Kind() == reflect.Ptr {
+		dbType = dbType.Elem()
+	}
+	modelType := reflect.TypeOf((*Model)(nil)).Elem()
+	if dbType.Kind() != reflect.Struct || modelType.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlcmapper: ValidateMappable needs struct types, got %s and %s", dbType, modelType)
+	}
+
+	errs := unmappableFields(nil, newConfig(opts).planFor(dbType, modelType), "")
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return &MapErrors{Errors: errs}
+}
+
+func unmappableFields(out []*MapError, plan *structPlan, parent string) []*MapError {
+	for _, fp := range plan.fields {
+		switch {
+		case fp.skip || !fp.settable:
+		case fp.group != nil:
+			out = unmappableFields(out, fp.group, parent+fp.name+".")
+		case fp.methodErr != nil:
+			out = append(out, &MapError{Field: parent + fp.name, Column: fp.column, Reason: "method tag", Err: fp.methodErr})
+		case fp.setter == nil && (fp.dbIndex != nil || fp.method != ""):
+			out = append(out, &MapError{Field: parent + fp.name, Column: fp.column, Reason: "no conversion from " + fp.dbType.String()})
+		}
+	}
+	return out
+}
+
+func (p MappingPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s -> %s\n", p.DB, p.Model)
+	for _, f := range p.Fields {
+		source := f.DBField
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(&b, "  %s <- %s (column %q): %s\n", f.Field, source, f.Column, f.Kind)
+	}
+	return b.String()
+}
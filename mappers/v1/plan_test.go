@@ -0,0 +1,843 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type sliceLenRow struct {
+	Tags  []string
+	Codes pgtype.Array[pgtype.Text]
+	Meta  []byte
+}
+
+type sliceLenModel struct {
+	Tags  []string
+	Codes []string
+	Meta  []string
+}
+
+func TestWithMaxSliceLen(t *testing.T) {
+	row := sliceLenRow{Tags: []string{"a", "b", "c"}}
+	_, err := AutoMapWithTags[sliceLenRow, sliceLenModel](row, WithMaxSliceLen(2))
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) || mapErr.Field != "Tags" || !strings.Contains(err.Error(), "exceeds limit 2") {
+		t.Fatalf("err = %v, want a MapError for Tags exceeding the limit", err)
+	}
+
+	row = sliceLenRow{Codes: pgtype.Array[pgtype.Text]{Elements: make([]pgtype.Text, 3), Valid: true}}
+	if _, err := AutoMapWithTags[sliceLenRow, sliceLenModel](row, WithMaxSliceLen(2)); err == nil {
+		t.Fatal("expected an error for a pgtype.Array over the limit")
+	}
+
+	row = sliceLenRow{Tags: []string{"a", "b"}}
+	if _, err := AutoMapWithTags[sliceLenRow, sliceLenModel](row, WithMaxSliceLen(2)); err != nil {
+		t.Fatalf("slice at the limit: %v", err)
+	}
+}
+
+func TestWithMaxSliceLenIgnoresJSONBytes(t *testing.T) {
+	row := sliceLenRow{Meta: []byte(`["a","b"]`)}
+	got, err := AutoMapWithTags[sliceLenRow, sliceLenModel](row, WithMaxSliceLen(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Meta) != 2 {
+		t.Fatalf("Meta = %v, want 2 elements", got.Meta)
+	}
+}
+
+type convertRow struct {
+	Small int32
+	Big   int64
+	Neg   int64
+	Ratio float64
+}
+
+type convertModel struct {
+	Small int64
+	Big   int8
+	Neg   uint16
+	Ratio int
+}
+
+func TestConvertNumericKinds(t *testing.T) {
+	got, err := AutoMapWithTags[convertRow, convertModel](convertRow{Small: 7, Big: 100, Neg: 3, Ratio: 2.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (convertModel{Small: 7, Big: 100, Neg: 3}); got != want {
+		t.Fatalf("got %+v, want %+v (float -> int needs WithLossyConvert)", got, want)
+	}
+}
+
+func TestConvertRejectsIntOverflow(t *testing.T) {
+	for _, row := range []convertRow{{Big: 300}, {Neg: -1}} {
+		_, err := AutoMapWithTags[convertRow, convertModel](row)
+		if err == nil || !strings.Contains(err.Error(), "overflows") {
+			t.Fatalf("row %+v: err = %v, want an overflow error", row, err)
+		}
+	}
+
+	got, err := AutoMapWithTags[convertRow, convertModel](convertRow{Big: 300, Ratio: 2.9}, WithLossyConvert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Big != 44 || got.Ratio != 2 {
+		t.Fatalf("got %+v, want wrapped Big=44 and truncated Ratio=2", got)
+	}
+}
+
+func TestPlansSharedAcrossCalls(t *testing.T) {
+	pair := typePair{from: reflect.TypeOf(convertRow{}), to: reflect.TypeOf(convertModel{})}
+	a, b := newConfig(nil), newConfig(nil)
+	if a.planFor(pair.from, pair.to) != b.planFor(pair.from, pair.to) {
+		t.Fatal("configs with the same settings should share a plan")
+	}
+	if newConfig([]Option{WithStrict()}).planFor(pair.from, pair.to) == a.planFor(pair.from, pair.to) {
+		t.Fatal("configs with different settings should not share a plan")
+	}
+	withFunc := newConfig([]Option{WithNow(time.Now)})
+	if _, ok := withFunc.planKey(pair); ok {
+		t.Fatal("a config holding a func should not use shared plans")
+	}
+}
+
+type registeredRow struct{ Temp float64 }
+
+func TestSharedPlansSeeLaterRegistrations(t *testing.T) {
+	type model struct{ Temp string }
+	if _, err := AutoMapWithTags[registeredRow, model](registeredRow{Temp: 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := AutoMapWithTags[registeredRow, model](registeredRow{Temp: 1.5})
+	if got.Temp != "" {
+		t.Fatalf("Temp = %q before registering a converter", got.Temp)
+	}
+	RegisterConverter(func(f float64) (string, error) { return strconv.FormatFloat(f, 'f', 1, 64), nil })
+	defer func() {
+		convertersMu.Lock()
+		globalConverters = globalConverters[:len(globalConverters)-1]
+		convertersMu.Unlock()
+		registrations.Add(1)
+	}()
+	got, err := AutoMapWithTags[registeredRow, model](registeredRow{Temp: 1.5})
+	if err != nil || got.Temp != "1.5" {
+		t.Fatalf("got %+v, %v; want the newly registered converter used", got, err)
+	}
+}
+
+type dateRow struct {
+	Dob  pgtype.Date
+	Day  pgtype.Date
+	When pgtype.Date
+}
+
+type dateModel struct {
+	Dob  string `db:"Dob,layout=02/01/2006"`
+	Day  string
+	When time.Time
+}
+
+func TestDateLayouts(t *testing.T) {
+	d := pgtype.Date{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), Valid: true}
+	row := dateRow{Dob: d, Day: d, When: d}
+
+	got, err := AutoMapWithTags[dateRow, dateModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Dob != "09/03/2024" || got.Day != "2024-03-09" {
+		t.Fatalf("default layout: got %+v", got)
+	}
+
+	got, err = AutoMapWithTags[dateRow, dateModel](row, WithDateLayout("Jan 2, 2006"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Dob != "09/03/2024" || got.Day != "Mar 9, 2024" {
+		t.Fatalf("field layout should win over WithDateLayout: got %+v", got)
+	}
+
+	inf := pgtype.Date{InfinityModifier: pgtype.Infinity, Valid: true}
+	got, err = AutoMapWithTags[dateRow, dateModel](dateRow{Dob: inf, Day: inf}, WithDateLayout("Jan 2, 2006"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Dob != "" || got.Day != "" {
+		t.Fatalf("infinity should render as \"\": got %+v", got)
+	}
+}
+
+func TestNullDateZeroesTimeField(t *testing.T) {
+	dst := dateModel{When: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, d := range []pgtype.Date{{}, {InfinityModifier: pgtype.NegativeInfinity, Valid: true}} {
+		if err := AutoMapInto(dateRow{When: d}, &dst); err != nil {
+			t.Fatal(err)
+		}
+		if !dst.When.IsZero() {
+			t.Fatalf("When = %v for %+v, want the zero time", dst.When, d)
+		}
+	}
+}
+
+type nestedAddrRow struct {
+	Street string
+	City   pgtype.Text
+}
+
+type nestedPtrRow struct {
+	ID   int64
+	Home *nestedAddrRow
+	Work *nestedAddrRow
+}
+
+type nestedAddr struct {
+	Street string
+	City   string
+}
+
+type nestedPtrModel struct {
+	ID   int64
+	Home nestedAddr
+	Work *nestedAddr
+}
+
+func TestAutoMapNestedPointers(t *testing.T) {
+	got, err := AutoMapWithTags[nestedPtrRow, nestedPtrModel](nestedPtrRow{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (nestedPtrModel{ID: 1}) {
+		t.Fatalf("nil nested pointers: got %+v, want zero Home and nil Work", got)
+	}
+
+	row := nestedPtrRow{
+		ID:   2,
+		Home: &nestedAddrRow{Street: "1 Main", City: pgtype.Text{String: "Oslo", Valid: true}},
+		Work: &nestedAddrRow{Street: "2 Side"},
+	}
+	got, err = AutoMapWithTags[nestedPtrRow, nestedPtrModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Home != (nestedAddr{Street: "1 Main", City: "Oslo"}) || got.Work == nil || *got.Work != (nestedAddr{Street: "2 Side"}) {
+		t.Fatalf("populated nested pointers: got %+v, Work %+v", got, got.Work)
+	}
+}
+
+type shippingRow struct {
+	Carrier  string
+	Tracking string
+}
+
+type orderRow struct {
+	ID      int64
+	Billing shippingRow // the wrong nested type
+}
+
+type billing struct {
+	Street string
+	Zip    string
+}
+
+type orderModel struct {
+	ID      int64
+	Billing billing
+}
+
+func TestErrorOnPartialNested(t *testing.T) {
+	row := orderRow{ID: 1, Billing: shippingRow{Carrier: "ups"}}
+	got, err := AutoMapWithTags[orderRow, orderModel](row)
+	if err != nil || got != (orderModel{ID: 1}) {
+		t.Fatalf("without the option a mismatch is silent: got %+v, %v", got, err)
+	}
+
+	_, err = AutoMapWithTags[orderRow, orderModel](row, WithErrorOnPartialNested())
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) || mapErr.Field != "Billing" {
+		t.Fatalf("err = %v, want a MapError for Billing", err)
+	}
+
+	type billingRow struct {
+		Street pgtype.Text
+		Zip    pgtype.Text
+	}
+	type goodRow struct {
+		ID      int64
+		Billing billingRow
+	}
+	good := goodRow{Billing: billingRow{Street: pgtype.Text{String: "s", Valid: true}}}
+	got, err = AutoMapWithTags[goodRow, orderModel](good, WithErrorOnPartialNested())
+	if err != nil || got.Billing.Street != "s" {
+		t.Fatalf("matching nested type: got %+v, %v", got, err)
+	}
+}
+
+type strictInnerRow struct{ Zip pgtype.Int8 }
+
+type strictOuterRow struct{ Billing strictInnerRow }
+
+type strictInner struct{ Zip uint8 }
+
+type strictOuter struct{ Billing strictInner }
+
+func TestErrorOnPartialNestedPrefixesPaths(t *testing.T) {
+	row := strictOuterRow{Billing: strictInnerRow{Zip: pgtype.Int8{Int64: 1000, Valid: true}}}
+	_, err := AutoMapWithTags[strictOuterRow, strictOuter](row, WithStrict(), WithErrorOnPartialNested())
+	var mapErr *MapError
+	if !errors.As(err, &mapErr) || mapErr.Field != "Billing.Zip" {
+		t.Fatalf("err = %v, want the nested path Billing.Zip", err)
+	}
+}
+
+type namedIDs []string
+
+type namedNums []int64
+
+type namedSliceRow struct {
+	Plain  []string
+	Ptr    []string
+	Flat   pgtype.FlatArray[string]
+	Widen  []int32
+	Texts  pgtype.Array[pgtype.Text]
+	Absent []string
+	Null   pgtype.Array[pgtype.Text]
+}
+
+type namedSliceModel struct {
+	Plain  namedIDs
+	Ptr    *namedIDs
+	Flat   *namedIDs
+	Widen  *namedNums
+	Texts  *namedIDs
+	Absent *namedIDs
+	Null   *namedIDs
+}
+
+func TestAutoMapNamedSlicePointers(t *testing.T) {
+	row := namedSliceRow{
+		Plain: []string{"a"},
+		Ptr:   []string{"b", "c"},
+		Flat:  pgtype.FlatArray[string]{"d"},
+		Widen: []int32{1, 2},
+		Texts: pgtype.Array[pgtype.Text]{Elements: []pgtype.Text{{String: "e", Valid: true}}, Valid: true},
+	}
+	got, err := AutoMapWithTags[namedSliceRow, namedSliceModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct{ got, want any }{
+		"Plain": {got.Plain, namedIDs{"a"}},
+		"Ptr":   {got.Ptr, &namedIDs{"b", "c"}},
+		"Flat":  {got.Flat, &namedIDs{"d"}},
+		"Widen": {got.Widen, &namedNums{1, 2}},
+		"Texts": {got.Texts, &namedIDs{"e"}},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", name, tc.got, tc.want)
+		}
+	}
+	if got.Absent != nil || got.Null != nil {
+		t.Errorf("nil and NULL sources should leave nil pointers, got %v, %v", got.Absent, got.Null)
+	}
+}
+
+type groupRow struct {
+	ID              int64
+	UserID          pgtype.Int8
+	UserName        pgtype.Text
+	UserAddressCity pgtype.Text
+}
+
+type groupAddress struct {
+	City string
+}
+
+type groupUser struct {
+	ID      int64
+	Name    string
+	Note    string
+	Address groupAddress
+}
+
+type groupModel struct {
+	ID     int64
+	UserID int64
+	User   *groupUser
+}
+
+type groupTagModel struct {
+	ID   int64
+	User groupUser `db:"user,prefix"`
+}
+
+type groupJSONRow struct {
+	User     []byte
+	UserID   pgtype.Int8
+	UserName pgtype.Text
+}
+
+type groupJSONModel struct {
+	User groupUser
+}
+
+type groupJSONTagModel struct {
+	User groupUser `db:"user,prefix"`
+}
+
+func TestPrefixGrouping(t *testing.T) {
+	row := groupRow{
+		ID:              7,
+		UserID:          pgtype.Int8{Int64: 42, Valid: true},
+		UserName:        pgtype.Text{String: "ann", Valid: true},
+		UserAddressCity: pgtype.Text{String: "Oslo", Valid: true},
+	}
+	want := groupUser{ID: 42, Name: "ann", Address: groupAddress{City: "Oslo"}}
+
+	got, err := AutoMapWithTags[groupRow, groupModel](row, WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 7 || got.UserID != 42 || got.User == nil || *got.User != want {
+		t.Fatalf("WithPrefixGrouping: got %+v, User %+v", got, show(got.User))
+	}
+
+	tagged, err := AutoMapWithTags[groupRow, groupTagModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without WithPrefixGrouping only tagged fields group, so the untagged
+	// Address inside the group stays empty.
+	if want := (groupUser{ID: 42, Name: "ann"}); tagged.User != want {
+		t.Fatalf("prefix tag: User = %+v, want %+v", tagged.User, want)
+	}
+	tagged, err = AutoMapWithTags[groupRow, groupTagModel](row, WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged.User != want {
+		t.Fatalf("prefix tag with grouping: User = %+v, want %+v", tagged.User, want)
+	}
+
+	plain, err := AutoMapWithTags[groupRow, groupModel](row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.User != nil {
+		t.Fatalf("without grouping: User = %+v, want nil", *plain.User)
+	}
+}
+
+func TestPrefixTagWinsOverDirectMatch(t *testing.T) {
+	row := groupJSONRow{
+		User:     []byte(`{"Name":"from json"}`),
+		UserID:   pgtype.Int8{Int64: 1, Valid: true},
+		UserName: pgtype.Text{String: "from columns", Valid: true},
+	}
+	direct, err := AutoMapWithTags[groupJSONRow, groupJSONModel](row, WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if direct.User.Name != "from json" {
+		t.Fatalf("WithPrefixGrouping: Name = %q, want the direct match", direct.User.Name)
+	}
+	tagged, err := AutoMapWithTags[groupJSONRow, groupJSONTagModel](row, WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged.User.Name != "from columns" || tagged.User.ID != 1 {
+		t.Fatalf("prefix tag: User = %+v, want it filled from user_* columns", tagged.User)
+	}
+}
+
+func TestPrefixGroupAllNullLeavesPointerNil(t *testing.T) {
+	got, err := AutoMapWithTags[groupRow, groupModel](groupRow{ID: 7}, WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.User != nil {
+		t.Fatalf("User = %+v, want nil for an all-NULL join side", *got.User)
+	}
+
+	dst := groupModel{User: &groupUser{Name: "stale"}}
+	if err := AutoMapInto(groupRow{ID: 7}, &dst, WithPrefixGrouping()); err != nil {
+		t.Fatal(err)
+	}
+	if dst.User != nil {
+		t.Fatalf("AutoMapInto: User = %+v, want nil", *dst.User)
+	}
+}
+
+func TestPrefixGroupAutoMapIntoKeepsUntouchedFields(t *testing.T) {
+	orig := &groupUser{Name: "old", Note: "keep"}
+	dst := groupModel{User: orig}
+	row := groupRow{UserName: pgtype.Text{String: "new", Valid: true}}
+	if err := AutoMapInto(row, &dst, WithPrefixGrouping()); err != nil {
+		t.Fatal(err)
+	}
+	if dst.User == nil || dst.User.Name != "new" || dst.User.Note != "keep" {
+		t.Fatalf("User = %+v, want Name updated and Note kept", show(dst.User))
+	}
+	if orig.Name != "old" {
+		t.Fatalf("the previous pointee was modified: %+v", *orig)
+	}
+}
+
+func TestExplainMappingPrefixGroup(t *testing.T) {
+	plan, err := ExplainMapping[groupRow, groupModel](WithPrefixGrouping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range plan.Fields {
+		got = append(got, f.Field+" "+f.Column+" "+f.DBField+" "+f.Kind)
+	}
+	want := []string{
+		"ID ID ID assign",
+		"UserID UserID UserID pgtype",
+		"User User user_* prefix group",
+		"User.ID user_id UserID pgtype",
+		"User.Name user_name UserName pgtype",
+		"User.Note user_note  unmatched",
+		"User.Address user_address user_address_* prefix group",
+		"User.Address.City user_address_city UserAddressCity pgtype",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("fields:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+type methodRow struct {
+	First pgtype.Text
+	Last  pgtype.Text
+}
+
+func (r methodRow) FullName() string { return r.First.String + " " + r.Last.String }
+
+func (r *methodRow) Initials() pgtype.Text {
+	return pgtype.Text{String: r.First.String[:1] + r.Last.String[:1], Valid: true}
+}
+
+func (r methodRow) Greeting(prefix string) string { return prefix + r.First.String }
+
+func (r methodRow) Split() (string, string) { return r.First.String, r.Last.String }
+
+type methodModel struct {
+	FullName string `db:"full_name,method"`
+	Initials string `db:"Initials,method"`
+}
+
+func TestMethodTag(t *testing.T) {
+	row := methodRow{First: pgtype.Text{String: "Ada", Valid: true}, Last: pgtype.Text{String: "Lovelace", Valid: true}}
+	want := methodModel{FullName: "Ada Lovelace", Initials: "AL"}
+	got, err := AutoMapWithTags[methodRow, methodModel](row)
+	if err != nil || got != want {
+		t.Fatalf("value: got %+v, %v; want %+v", got, err, want)
+	}
+	got, err = AutoMapWithTags[*methodRow, methodModel](&row)
+	if err != nil || got != want {
+		t.Fatalf("pointer: got %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestMethodTagErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		mapFn func() error
+		want  string
+	}{
+		{"takes arguments", func() error {
+			type model struct {
+				Greeting string `db:"greeting,method"`
+			}
+			_, err := AutoMapWithTags[methodRow, model](methodRow{})
+			return err
+		}, "must take no arguments and return one value"},
+		{"returns two values", func() error {
+			type model struct {
+				Split string `db:"split,method"`
+			}
+			_, err := AutoMapWithTags[methodRow, model](methodRow{})
+			return err
+		}, "must take no arguments and return one value"},
+		{"no such method", func() error {
+			type model struct {
+				Age int `db:"age,method"`
+			}
+			_, err := AutoMapWithTags[methodRow, model](methodRow{})
+			return err
+		}, `no method on sqlcmapper.methodRow for column "age"`},
+	} {
+		err := tc.mapFn()
+		var mapErr *MapError
+		if !errors.As(err, &mapErr) || mapErr.Reason != "method tag" || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want a method tag MapError mentioning %q", tc.name, err, tc.want)
+		}
+	}
+
+	type model struct {
+		Greeting string `db:"greeting,method"`
+	}
+	if err := ValidateMappable[methodRow, model](); err == nil || !strings.Contains(err.Error(), "method tag") {
+		t.Fatalf("ValidateMappable: err = %v, want the method tag error", err)
+	}
+}
+
+type dupRow struct {
+	Email pgtype.Text
+	Name  pgtype.Text
+}
+
+type dupModel struct {
+	Email   string
+	Contact string `db:"email"`
+	Name    string
+	Alias   string `db:"Name"`
+}
+
+func TestDuplicateColumns(t *testing.T) {
+	row := dupRow{Email: pgtype.Text{String: "a@b", Valid: true}, Name: pgtype.Text{String: "ann", Valid: true}}
+	got, err := AutoMapWithTags[dupRow, dupModel](row)
+	if err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+	if got.Contact != "a@b" || got.Alias != "ann" {
+		t.Fatalf("lenient: got %+v, want both fields filled", got)
+	}
+
+	_, err = AutoMapWithTags[dupRow, dupModel](row, WithStrict())
+	var multi *MapErrors
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("strict: err = %v, want two duplicate errors", err)
+	}
+	if e := multi.Errors[0]; e.Field != "Email, Contact" || e.Reason != "column is mapped by more than one field" {
+		t.Fatalf("first error = %+v", e)
+	}
+	if e := multi.Errors[1]; e.Field != "Name, Alias" {
+		t.Fatalf("second error = %+v", e)
+	}
+
+	type single struct {
+		Email   string
+		Contact string `db:"email"`
+	}
+	var mapErr *MapError
+	if _, err := AutoMapWithTags[dupRow, single](row, WithStrict()); !errors.As(err, &mapErr) || errors.As(err, &multi) {
+		t.Fatalf("one duplicate: err = %v, want a single *MapError", err)
+	}
+}
+
+type plainScalarRow struct {
+	Score  pgtype.Float8
+	Active pgtype.Bool
+	Rank   pgtype.Float8
+}
+
+type rating float64
+
+type plainScalarModel struct {
+	Score  float64
+	Active bool
+	Rank   rating
+}
+
+func TestAutoMapFloat8AndBoolIntoPlainTypes(t *testing.T) {
+	row := plainScalarRow{
+		Score:  pgtype.Float8{Float64: 2.5, Valid: true},
+		Active: pgtype.Bool{Bool: true, Valid: true},
+		Rank:   pgtype.Float8{Float64: 4, Valid: true},
+	}
+	got, err := AutoMapWithTags[plainScalarRow, plainScalarModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (plainScalarModel{Score: 2.5, Active: true, Rank: 4}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	dst := plainScalarModel{Score: 1, Active: true, Rank: 1}
+	if err := AutoMapInto(plainScalarRow{}, &dst, WithStrict()); err != nil {
+		t.Fatal(err)
+	}
+	if dst != (plainScalarModel{}) {
+		t.Fatalf("NULL: got %+v, want zero values", dst)
+	}
+}
+
+func TestScalarMapFloat8AndBool(t *testing.T) {
+	f, err := ScalarMap[pgtype.Float8, float64](pgtype.Float8{Float64: 0.25, Valid: true})
+	if err != nil || f != 0.25 {
+		t.Fatalf("float64: got %v, %v; want 0.25", f, err)
+	}
+	b, err := ScalarMap[pgtype.Bool, bool](pgtype.Bool{Bool: true, Valid: true})
+	if err != nil || !b {
+		t.Fatalf("bool: got %v, %v; want true", b, err)
+	}
+	if b, err := ScalarMap[pgtype.Bool, bool](pgtype.Bool{}); err != nil || b {
+		t.Fatalf("NULL bool: got %v, %v; want false", b, err)
+	}
+}
+
+type validateRow struct {
+	Name  pgtype.Text
+	Count pgtype.Int4
+	Tags  pgtype.Array[pgtype.Text]
+}
+
+type validateOK struct {
+	Name    string
+	Count   int32
+	Tags    []string
+	Missing string // unmatched fields are not reported
+}
+
+type validateBad struct {
+	Name  chan int
+	Count []int
+	Tags  []string
+}
+
+func TestValidateMappable(t *testing.T) {
+	if err := ValidateMappable[validateRow, validateOK](); err != nil {
+		t.Fatalf("valid model: %v", err)
+	}
+	if err := ValidateMappable[*validateRow, validateOK](); err != nil {
+		t.Fatalf("pointer db type: %v", err)
+	}
+
+	err := ValidateMappable[validateRow, validateBad]()
+	var multi *MapErrors
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 || multi.Errors[0].Field != "Name" || multi.Errors[1].Field != "Count" {
+		t.Fatalf("err = %v, want errors for Name and Count", err)
+	}
+
+	type oneBad struct{ Count []int }
+	var mapErr *MapError
+	if err := ValidateMappable[validateRow, oneBad](); !errors.As(err, &mapErr) || errors.As(err, &multi) {
+		t.Fatalf("one bad field: err = %v, want a single *MapError", err)
+	}
+
+	// A converter supplies the missing path.
+	conv := WithConverter(func(pgtype.Int4) ([]int, error) { return nil, nil })
+	if err := ValidateMappable[validateRow, oneBad](conv); err != nil {
+		t.Fatalf("with converter: %v", err)
+	}
+
+	if err := ValidateMappable[int, validateOK](); err == nil {
+		t.Fatal("expected an error for a non-struct db type")
+	}
+}
+
+type explainRow struct {
+	ID    int64
+	Name  pgtype.Text
+	Count pgtype.Int4
+	Note  pgtype.Text
+}
+
+type explainModel struct {
+	ID      int64
+	Name    *string
+	Count   []int
+	Missing string
+	Secret  string `db:"-"`
+	note    string
+}
+
+func TestExplainMapping(t *testing.T) {
+	plan, err := ExplainMapping[explainRow, explainModel]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.DB != reflect.TypeOf(explainRow{}) || plan.Model != reflect.TypeOf(explainModel{}) {
+		t.Fatalf("types = %v, %v", plan.DB, plan.Model)
+	}
+	want := []FieldMapping{
+		{Field: "ID", Column: "ID", DBField: "ID", Kind: "assign"},
+		{Field: "Name", Column: "Name", DBField: "Name", Kind: "pgtype"},
+		{Field: "Count", Column: "Count", DBField: "Count", Kind: "unsupported"},
+		{Field: "Missing", Column: "Missing", Kind: "unmatched"},
+		{Field: "Secret", Column: "-", Kind: "skipped"},
+		{Field: "note", Column: "note", DBField: "Note", Kind: "unsettable"},
+	}
+	if !reflect.DeepEqual(plan.Fields, want) {
+		t.Fatalf("fields:\n%+v\nwant:\n%+v", plan.Fields, want)
+	}
+
+	// The plan is the one the mapper uses, so per-call options show up.
+	plan, err = ExplainMapping[explainRow, explainModel](WithConverter(func(pgtype.Int4) ([]int, error) { return nil, nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := plan.Fields[2].Kind; kind != "converter" {
+		t.Fatalf("Count with converter: Kind = %q, want %q", kind, "converter")
+	}
+
+	if _, err := ExplainMapping[explainRow, string](); err == nil {
+		t.Fatal("expected an error for a non-struct model type")
+	}
+}
+
+type driftRow struct {
+	ID     int64
+	Emial  pgtype.Text // typo of Email
+	Legacy pgtype.Text // not in the model
+}
+
+type driftModel struct {
+	ID       int64
+	Email    string
+	Nickname string // column not added yet
+}
+
+func TestWithAllowMissingColumns(t *testing.T) {
+	_, err := AutoMapWithTags[driftRow, driftModel](driftRow{}, WithStrict(), WithAllowMissingColumns(), WithCollectErrors())
+	var multi *MapErrors
+	if !errors.As(err, &multi) || len(multi.Errors) != 1 {
+		t.Fatalf("err = %v, want only the Email typo", err)
+	}
+	if e := multi.Errors[0]; e.Field != "Email" || !strings.Contains(e.Reason, "did you mean db field Emial?") {
+		t.Fatalf("error = %+v, want Email pointed at Emial", e)
+	}
+
+	_, err = AutoMapWithTags[driftRow, driftModel](driftRow{}, WithStrict(), WithCollectErrors())
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 || !strings.Contains(multi.Errors[1].Reason, "unmatched db fields: Emial, Legacy") {
+		t.Fatalf("strict only: err = %v, want Email and Nickname reported", err)
+	}
+
+	type ahead struct {
+		ID       int64
+		Emial    string
+		Legacy   string
+		Nickname string
+	}
+	if _, err := AutoMapWithTags[driftRow, ahead](driftRow{}, WithStrict(), WithAllowMissingColumns()); err != nil {
+		t.Fatalf("no near match: %v", err)
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"Email", "Emial", true},
+		{"user_name", "UserNmae", true},
+		{"created_at", "CreatedAt", true},
+		{"Nickname", "Legacy", false},
+		{"ID", "OrgID", false},
+		{"id", "ix", true},
+		{"id", "xy", false},
+	} {
+		if got := similarNames(tc.a, tc.b); got != tc.want {
+			t.Errorf("similarNames(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
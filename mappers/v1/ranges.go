@@ -0,0 +1,39 @@
+package sqlcmapper
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Range helpers
+/////////////////////
+
+// Unbounded ends come back as nil. NULL and empty ranges yield nil for both
+// ends too; check r.Valid and r.LowerType == pgtype.Empty when the
+// difference from (-inf,+inf) matters.
+
+func PgInt8RangeToBounds(r pgtype.Range[pgtype.Int8]) (lo, hi *int64, loInc, hiInc bool) {
+	return rangeBounds(r, PgInt8ToInt64Ptr)
+}
+
+// PgTimestamptzRangeToBounds treats infinite bounds like unbounded ones.
+func PgTimestamptzRangeToBounds(r pgtype.Range[pgtype.Timestamptz]) (lo, hi *time.Time, loInc, hiInc bool) {
+	return rangeBounds(r, PgTimestamptzToTimePtr)
+}
+
+func rangeBounds[T any, V any](r pgtype.Range[T], conv func(T) *V) (lo, hi *V, loInc, hiInc bool) {
+	if !r.Valid || r.LowerType == pgtype.Empty {
+		return nil, nil, false, false
+	}
+	if r.LowerType != pgtype.Unbounded {
+		lo = conv(r.Lower)
+		loInc = lo != nil && r.LowerType == pgtype.Inclusive
+	}
+	if r.UpperType != pgtype.Unbounded {
+		hi = conv(r.Upper)
+		hiInc = hi != nil && r.UpperType == pgtype.Inclusive
+	}
+	return lo, hi, loInc, hiInc
+}
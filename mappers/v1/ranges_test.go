@@ -0,0 +1,92 @@
+package sqlcmapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func pgInt8(n int64) pgtype.Int8 { return pgtype.Int8{Int64: n, Valid: true} }
+
+func TestPgInt8RangeToBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		r            pgtype.Range[pgtype.Int8]
+		lo, hi       *int64
+		loInc, hiInc bool
+	}{
+		{name: "null", r: pgtype.Range[pgtype.Int8]{}},
+		{name: "empty", r: pgtype.Range[pgtype.Int8]{LowerType: pgtype.Empty, UpperType: pgtype.Empty, Valid: true}},
+		{
+			name: "bounded [1,10)",
+			r:    pgtype.Range[pgtype.Int8]{Lower: pgInt8(1), Upper: pgInt8(10), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			lo:   ptr(int64(1)), hi: ptr(int64(10)), loInc: true,
+		},
+		{
+			name: "bounded (1,10]",
+			r:    pgtype.Range[pgtype.Int8]{Lower: pgInt8(1), Upper: pgInt8(10), LowerType: pgtype.Exclusive, UpperType: pgtype.Inclusive, Valid: true},
+			lo:   ptr(int64(1)), hi: ptr(int64(10)), hiInc: true,
+		},
+		{
+			name: "half-open [5,)",
+			r:    pgtype.Range[pgtype.Int8]{Lower: pgInt8(5), LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+			lo:   ptr(int64(5)), loInc: true,
+		},
+		{
+			name: "half-open (,5)",
+			r:    pgtype.Range[pgtype.Int8]{Upper: pgInt8(5), LowerType: pgtype.Unbounded, UpperType: pgtype.Exclusive, Valid: true},
+			hi:   ptr(int64(5)),
+		},
+	} {
+		lo, hi, loInc, hiInc := PgInt8RangeToBounds(tc.r)
+		if !eqPtr(lo, tc.lo) || !eqPtr(hi, tc.hi) || loInc != tc.loInc || hiInc != tc.hiInc {
+			t.Errorf("%s: got %v, %v, %v, %v", tc.name, show(lo), show(hi), loInc, hiInc)
+		}
+	}
+}
+
+func TestPgTimestamptzRangeToBounds(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	ts := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+
+	lo, hi, loInc, hiInc := PgTimestamptzRangeToBounds(pgtype.Range[pgtype.Timestamptz]{
+		Lower: ts(start), Upper: ts(end), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true,
+	})
+	if lo == nil || !lo.Equal(start) || hi == nil || !hi.Equal(end) || !loInc || hiInc {
+		t.Errorf("bounded: got %v, %v, %v, %v", lo, hi, loInc, hiInc)
+	}
+
+	lo, hi, loInc, _ = PgTimestamptzRangeToBounds(pgtype.Range[pgtype.Timestamptz]{
+		Lower: ts(start), LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true,
+	})
+	if lo == nil || !lo.Equal(start) || hi != nil || !loInc {
+		t.Errorf("half-open: got %v, %v", lo, hi)
+	}
+
+	lo, hi, _, _ = PgTimestamptzRangeToBounds(pgtype.Range[pgtype.Timestamptz]{
+		Lower: pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}, Upper: ts(end),
+		LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true,
+	})
+	if lo != nil || hi == nil {
+		t.Errorf("-infinity lower bound: got %v, %v; want nil lower", lo, hi)
+	}
+
+	if lo, hi, _, _ := PgTimestamptzRangeToBounds(pgtype.Range[pgtype.Timestamptz]{LowerType: pgtype.Empty, UpperType: pgtype.Empty, Valid: true}); lo != nil || hi != nil {
+		t.Error("empty range should give nil bounds")
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func eqPtr[T comparable](a, b *T) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func show[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,70 @@
+package sqlcmapper
+
+import (
+	"reflect"
+	"sync"
+)
+
+/////////////////////
+// Mapper registry
+/////////////////////
+
+type Mapper[From any, To any] interface {
+	Map(From) To
+}
+
+type typePair struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+// Registry stores mappers keyed by their From/To type pair. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	mappers map[typePair]interface{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{mappers: make(map[typePair]interface{})}
+}
+
+// DefaultRegistry is used by Register and Get.
+var DefaultRegistry = NewRegistry()
+
+func Register[From any, To any](m Mapper[From, To]) {
+	RegisterIn[From, To](DefaultRegistry, m)
+}
+
+func Get[From any, To any]() (Mapper[From, To], bool) {
+	return GetFrom[From, To](DefaultRegistry)
+}
+
+// RegisterIn adds m to r, replacing any mapper already registered for the
+// same type pair. It panics if m is nil.
+func RegisterIn[From any, To any](r *Registry, m Mapper[From, To]) {
+	if m == nil {
+		panic("sqlcmapper: RegisterIn: nil mapper")
+	}
+	key := pairOf[From, To]()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mappers[key] = m
+}
+
+func GetFrom[From any, To any](r *Registry) (Mapper[From, To], bool) {
+	r.mu.RLock()
+	m, ok := r.mappers[pairOf[From, To]()]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return m.(Mapper[From, To]), true
+}
+
+func pairOf[From any, To any]() typePair {
+	return typePair{
+		from: reflect.TypeOf((*From)(nil)).Elem(),
+		to:   reflect.TypeOf((*To)(nil)).Elem(),
+	}
+}
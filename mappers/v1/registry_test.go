@@ -0,0 +1,46 @@
+package sqlcmapper
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type registryKey int
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if _, ok := GetFrom[registryKey, string](r); ok {
+		t.Fatal("empty registry returned a mapper")
+	}
+
+	RegisterIn[registryKey, string](r, NewGenericMapper(func(k registryKey) string { return "v" + strconv.Itoa(int(k)) }))
+	m, ok := GetFrom[registryKey, string](r)
+	if !ok || m.Map(1) != "v1" {
+		t.Fatalf("GetFrom: ok = %v", ok)
+	}
+	if _, ok := GetFrom[registryKey, int](r); ok {
+		t.Fatal("a mapper for another To type was returned")
+	}
+	if _, ok := Get[registryKey, string](); ok {
+		t.Fatal("DefaultRegistry sees a mapper registered in another registry")
+	}
+
+	RegisterIn[registryKey, string](r, NewGenericMapper(func(registryKey) string { return "replaced" }))
+	if m, _ := GetFrom[registryKey, string](r); m.Map(1) != "replaced" {
+		t.Fatal("a second registration did not replace the first")
+	}
+}
+
+func TestRegisterNilMapperPanics(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "nil mapper") {
+			t.Fatalf("panic = %q, want a nil mapper panic", msg)
+		}
+		if _, ok := Get[registryKey, bool](); ok {
+			t.Fatal("the nil mapper was stored")
+		}
+	}()
+	Register[registryKey, bool](nil)
+}
@@ -0,0 +1,193 @@
+package sqlcmapper
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+/////////////////////
+// Slice helpers
+/////////////////////
+
+// MapSliceWithIndex maps fs and builds a lookup of the results by keyFn in a
+// single pass. When two results share a key the later one wins in the map;
+// the slice always keeps every element.
+func MapSliceWithIndex[From any, To any, K comparable](fs []From, mapFn func(From) To, keyFn func(To) K) ([]To, map[K]To) {
+	out := make([]To, len(fs))
+	index := make(map[K]To, len(fs))
+	for i, f := range fs {
+		t := mapFn(f)
+		out[i] = t
+		index[keyFn(t)] = t
+	}
+	return out, index
+}
+
+// StreamMap pulls db values from next one at a time, maps them with fn and
+// hands each result to yield, so nothing is collected in memory. next reports
+// false when the source is exhausted. The first error from next, fn or yield
+// stops the loop and is returned.
+func StreamMap[DB any, Model any](next func() (DB, bool, error), fn func(DB) (Model, error), yield func(Model) error) error {
+	for {
+		dbItem, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		mapped, err := fn(dbItem)
+		if err != nil {
+			return err
+		}
+		if err := yield(mapped); err != nil {
+			return err
+		}
+	}
+}
+
+// MapSliceUntil maps fs in order until fn returns false. The element that
+// stopped the loop is not included.
+func MapSliceUntil[From any, To any](fs []From, fn func(From) (To, bool)) []To {
+	out := make([]To, 0, len(fs))
+	for _, f := range fs {
+		t, ok := fn(f)
+		if !ok {
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// Partition groups items into buckets by keyFn, keeping their relative order
+// within each bucket.
+func Partition[To any, K comparable](items []To, keyFn func(To) K) map[K][]To {
+	out := make(map[K][]To)
+	for _, item := range items {
+		k := keyFn(item)
+		out[k] = append(out[k], item)
+	}
+	return out
+}
+
+// MapReduce maps fs and folds each mapped element, in order, into an
+// accumulator starting at init, returning both in a single pass.
+func MapReduce[From any, To any, Acc any](fs []From, mapFn func(From) To, reduceFn func(Acc, To) Acc, init Acc) ([]To, Acc) {
+	out := make([]To, len(fs))
+	acc := init
+	for i, f := range fs {
+		out[i] = mapFn(f)
+		acc = reduceFn(acc, out[i])
+	}
+	return out, acc
+}
+
+// FlatMap maps each element to zero or more results and concatenates them in
+// order. The result is never nil.
+func FlatMap[From any, To any](fs []From, fn func(From) []To) []To {
+	out := make([]To, 0, len(fs))
+	for _, f := range fs {
+		out = append(out, fn(f)...)
+	}
+	return out
+}
+
+// MapChan maps every value received from in onto the returned channel, which
+// has the given buffer size and is closed once in is closed and drained. The
+// mapping goroutine blocks while the output is full, so the consumer must
+// keep reading until the output closes (or ensure in is closed and drain
+// what remains); abandoning it leaks the goroutine. MapChanCtx can be
+// cancelled instead.
+func MapChan[From any, To any](in <-chan From, fn func(From) To, buffer int) <-chan To {
+	return MapChanCtx(context.Background(), in, fn, buffer)
+}
+
+// MapChanCtx is MapChan with a context: once ctx is done the mapping
+// goroutine stops, whether waiting on in or on the consumer, and closes the
+// output. Values still in flight are dropped.
+func MapChanCtx[From any, To any](ctx context.Context, in <-chan From, fn func(From) To, buffer int) <-chan To {
+	out := make(chan To, buffer)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case f, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- fn(f):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// MapSliceParallelE maps fs with up to workers goroutines (GOMAXPROCS when
+// workers <= 0), keeping results in input order. The first error from fn, or
+// ctx being done, cancels the context handed to the other workers so they stop
+// picking up elements; it is returned once every worker has exited. A ctx
+// that is already done returns its error without calling fn.
+func MapSliceParallelE[From any, To any](ctx context.Context, fs []From, workers int, fn func(context.Context, From) (To, error)) ([]To, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(fs) {
+		workers = len(fs)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make([]To, len(fs))
+	var (
+		next     atomic.Int64
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(fs) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					fail(err)
+					return
+				}
+				t, err := fn(ctx, fs[i])
+				if err != nil {
+					fail(fmt.Errorf("sqlcmapper: element %d: %w", i, err))
+					return
+				}
+				out[i] = t
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
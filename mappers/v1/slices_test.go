@@ -0,0 +1,268 @@
+package sqlcmapper
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMapSliceParallelEKeepsOrder(t *testing.T) {
+	in := make([]int, 1000)
+	for i := range in {
+		in[i] = i
+	}
+	got, err := MapSliceParallelE(context.Background(), in, 8, func(_ context.Context, i int) (string, error) {
+		if i%7 == 0 {
+			time.Sleep(time.Microsecond)
+		}
+		return strconv.Itoa(i), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range got {
+		if s != strconv.Itoa(i) {
+			t.Fatalf("got[%d] = %q, want %q", i, s, strconv.Itoa(i))
+		}
+	}
+}
+
+func TestMapSliceParallelECancelsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	var calls atomic.Int64
+	_, err := MapSliceParallelE(context.Background(), make([]int, 100), 2, func(ctx context.Context, _ int) (int, error) {
+		// The first call fails; the other worker blocks until it sees the
+		// cancellation, so no further element may be picked up.
+		if calls.Add(1) == 1 {
+			return 0, boom
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if n := calls.Load(); n > 2 {
+		t.Fatalf("fn called %d times after the first error, want at most 2", n)
+	}
+}
+
+func TestMapSliceParallelECancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, n := range []int{0, 10} {
+		called := false
+		_, err := MapSliceParallelE(ctx, make([]int, n), 4, func(context.Context, int) (int, error) {
+			called = true
+			return 0, nil
+		})
+		if !errors.Is(err, context.Canceled) || called {
+			t.Fatalf("%d elements: err = %v, called = %v; want context.Canceled without calls", n, err, called)
+		}
+	}
+}
+
+func TestMapSliceParallelENoLeakedGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		MapSliceParallelE(context.Background(), make([]int, 50), 8, func(_ context.Context, v int) (int, error) {
+			if i%2 == 0 {
+				return 0, errors.New("fail")
+			}
+			return v, nil
+		})
+	}
+	// Exited goroutines can take a moment to be reaped.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("%d goroutines running, %d before", n, before)
+	}
+}
+
+type parallelRow struct {
+	Name pgtype.Text
+}
+
+type parallelModel struct {
+	Name string
+}
+
+type parallelCtxKey struct{}
+
+// TestAutoMapSliceWithTagsParallelEContext runs under -race to cover the
+// workers sharing one config whose ctx is published by a sync.Once.
+func TestAutoMapSliceWithTagsParallelEContext(t *testing.T) {
+	rows := make([]parallelRow, 200)
+	for i := range rows {
+		rows[i] = parallelRow{Name: pgtype.Text{String: strconv.Itoa(i), Valid: true}}
+	}
+	ctx := context.WithValue(context.Background(), parallelCtxKey{}, "tenant")
+	conv := WithContextConverter(func(ctx context.Context, v pgtype.Text) (string, error) {
+		tenant, _ := ctx.Value(parallelCtxKey{}).(string)
+		return tenant + ":" + v.String, nil
+	})
+	got, err := AutoMapSliceWithTagsParallelE[parallelRow, parallelModel](ctx, rows, 8, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range got {
+		if want := "tenant:" + strconv.Itoa(i); m.Name != want {
+			t.Fatalf("got[%d].Name = %q, want %q", i, m.Name, want)
+		}
+	}
+}
+
+func TestAutoMapSliceWithTagsParallelEFirstError(t *testing.T) {
+	rows := make([]parallelRow, 50)
+	rows[10] = parallelRow{Name: pgtype.Text{String: "bad", Valid: true}}
+	conv := WithConverter(func(v pgtype.Text) (string, error) {
+		if v.String == "bad" {
+			return "", errors.New("bad row")
+		}
+		return v.String, nil
+	})
+	got, err := AutoMapSliceWithTagsParallelE[parallelRow, parallelModel](context.Background(), rows, 4, conv)
+	var mapErr *MapError
+	if got != nil || !errors.As(err, &mapErr) || mapErr.Field != "Name" {
+		t.Fatalf("got %d results, err = %v; want nil and a MapError for Name", len(got), err)
+	}
+}
+
+// sliceSource returns a next func over items, as StreamMap expects.
+func sliceSource[T any](items []T) func() (T, bool, error) {
+	i := 0
+	return func() (T, bool, error) {
+		if i == len(items) {
+			return *new(T), false, nil
+		}
+		i++
+		return items[i-1], true, nil
+	}
+}
+
+func TestStreamMap(t *testing.T) {
+	var got []string
+	err := StreamMap(sliceSource([]int{1, 2, 3}),
+		func(i int) (string, error) { return strconv.Itoa(i * 10), nil },
+		func(s string) error { got = append(got, s); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "10,20,30" {
+		t.Fatalf("got %v, want [10 20 30]", got)
+	}
+}
+
+func TestStreamMapStopsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	identity := func(i int) (int, error) { return i, nil }
+	for _, tc := range []struct {
+		name  string
+		next  func() (int, bool, error)
+		fn    func(int) (int, error)
+		yield func(int) error
+		want  int // elements yielded before the error
+	}{
+		{"next", func() (int, bool, error) { return 0, false, boom }, identity, nil, 0},
+		{"fn", sliceSource([]int{1, 2, 3}), func(i int) (int, error) {
+			if i == 2 {
+				return 0, boom
+			}
+			return i, nil
+		}, nil, 1},
+		{"yield", sliceSource([]int{1, 2, 3}), identity, func(i int) error {
+			if i == 2 {
+				return boom
+			}
+			return nil
+		}, 1},
+	} {
+		yielded := 0
+		yield := func(i int) error {
+			if tc.yield != nil {
+				if err := tc.yield(i); err != nil {
+					return err
+				}
+			}
+			yielded++
+			return nil
+		}
+		if err := StreamMap(tc.next, tc.fn, yield); !errors.Is(err, boom) || yielded != tc.want {
+			t.Errorf("%s: err = %v after %d elements, want %v after %d", tc.name, err, yielded, boom, tc.want)
+		}
+	}
+}
+
+func TestMapChanClosesWithInput(t *testing.T) {
+	in := make(chan int)
+	out := MapChan(in, strconv.Itoa, 0)
+	go func() {
+		for i := 0; i < 3; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	var got []string
+	for s := range out {
+		got = append(got, s)
+	}
+	if strings.Join(got, ",") != "0,1,2" {
+		t.Fatalf("got %v, want [0 1 2]", got)
+	}
+}
+
+func TestMapChanCtxCancelStopsWorker(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// Cancelled while waiting on the input.
+	ctx, cancel := context.WithCancel(context.Background())
+	idle := MapChanCtx(ctx, make(chan int), strconv.Itoa, 0)
+	cancel()
+
+	// Cancelled while blocked on a consumer that stopped reading.
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	in := make(chan int, 2)
+	in <- 1
+	in <- 2
+	blocked := MapChanCtx(ctx2, in, strconv.Itoa, 0)
+	if got := <-blocked; got != "1" {
+		t.Fatalf("first value = %q, want %q", got, "1")
+	}
+	cancel2()
+
+	for _, out := range []<-chan string{idle, blocked} {
+		select {
+		case <-waitClosed(out):
+		case <-time.After(time.Second):
+			t.Fatal("output not closed after cancellation")
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("%d goroutines running, %d before", n, before)
+	}
+}
+
+// waitClosed drains ch and reports when it is closed.
+func waitClosed[T any](ch <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,393 @@
+package sqlcmapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// db tag options
+/////////////////////
+
+// A db tag may carry comma-separated options after the column name, e.g.
+// `db:"created_at,epoch"` or `db:"ends_at,layout=2006-01-02"`. As with
+// encoding/json, only the part before the first comma is the column name
+// and `db:"-"` skips the field. `epoch` and `epochmillis` convert between
+// timestamps and Unix-time integers in either direction. Numeric columns
+// holding whole numbers can feed integer fields with `db:"total,int"`
+// (`int=trunc` drops fractions instead of failing). `db:"timeout,duration"`
+// reads an integer column as nanoseconds (`duration=us`, `ms` or `s` change
+// the unit) into a time.Duration or its String form. `db:"blob,text"` reads
+// bytea as UTF-8 text (`text=strict` rejects invalid UTF-8 instead of
+// replacing it). `db:"user,prefix"` on a nested struct field fills it from
+// user_* columns (see WithPrefixGrouping). Unknown options are ignored, or
+// reported under WithStrict.
+
+type tagOptions map[string]string
+
+var knownTagOptions = map[string]bool{
+	"epoch":       true,
+	"epochmillis": true,
+	"nano":        true,
+	"rfc3339nano": true,
+	"layout":      true,
+	"asstring":    true,
+	"scale":       true,
+	"prec":        true,
+	"int":         true,
+	"age":         true,
+	"duration":    true,
+	"intbool":     true,
+	"method":      true,
+	"prefix":      true,
+	"text":        true,
+	"round":       true,
+	"boolstr":     true,
+	// Accepted for encoding/json and sqlx familiarity; no effect here.
+	"omitempty": true,
+}
+
+func parseDBTag(tag string) (string, tagOptions) {
+	name, rest, found := strings.Cut(tag, ",")
+	if !found {
+		return name, nil
+	}
+	opts := tagOptions{}
+	for _, opt := range strings.Split(rest, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		if key != "" {
+			opts[key] = val
+		}
+	}
+	return name, opts
+}
+
+func (o tagOptions) has(key string) bool {
+	_, ok := o[key]
+	return ok
+}
+
+// unknown lists options that are neither built in nor registered with
+// WithTagOption on cfg.
+func (o tagOptions) unknown(cfg *config) []string {
+	var out []string
+	for key := range o {
+		if _, custom := cfg.tagHandlers[key]; !knownTagOptions[key] && !custom {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TagOptionHandler implements a custom tag option registered with
+// WithTagOption. opt is the text after "=" (empty for a bare option), dbVal
+// the db field value and dst the settable model field.
+type TagOptionHandler func(opt string, dbVal any, dst reflect.Value) error
+
+// customTagSetter returns a setter for the first option on the field, in
+// name order, that has a handler on cfg.
+func customTagSetter(opts tagOptions, cfg *config) *fieldSetter {
+	if len(cfg.tagHandlers) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		if _, ok := cfg.tagHandlers[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	handler, opt := cfg.tagHandlers[keys[0]], opts[keys[0]]
+	return &fieldSetter{kind: "tag option " + keys[0], set: func(field, dbField reflect.Value) error {
+		return handler(opt, dbField.Interface(), field)
+	}}
+}
+
+// applyTagOptions handles conversions requested through tag options. It
+// reports false when no option applies to this db/model type pair.
+func applyTagOptions(field, dbField reflect.Value, opts tagOptions, cfg *config) (bool, error) {
+	if len(opts) == 0 {
+		return false, nil
+	}
+	if (opts.has("epoch") || opts.has("epochmillis")) && (field.Type() == timeType || field.Type() == timePtrType) {
+		if n, valid, ok := intDBValue(dbField); ok {
+			t := time.Unix(n, 0).UTC()
+			if opts.has("epochmillis") {
+				t = time.UnixMilli(n).UTC()
+			}
+			return setTimeOrPtr(field, t, !valid), nil
+		}
+	}
+	switch v := dbField.Interface().(type) {
+	case pgtype.Timestamptz:
+		return setTimestamptzOption(field, cfg.timestamptz(dbField), opts, cfg)
+	case []byte:
+		if mode, ok := opts["text"]; ok {
+			var str *string
+			switch mode {
+			case "":
+				str = PgByteaToStringPtr(v)
+			case "strict":
+				var err error
+				if str, err = PgByteaToValidStringPtr(v); err != nil {
+					return false, err
+				}
+			default:
+				return false, fmt.Errorf("invalid text mode %q", mode)
+			}
+			if str == nil {
+				return setStringOrPtr(field, "", true)
+			}
+			return setStringOrPtr(field, *str, false)
+		}
+	case pgtype.Bool:
+		if opts.has("boolstr") {
+			if !v.Valid {
+				return setStringOrPtr(field, "", true)
+			}
+			return setStringOrPtr(field, strconv.FormatBool(v.Bool), false)
+		}
+	case pgtype.Int4:
+		if opts.has("intbool") {
+			return setBoolOrPtr(field, PgInt4ToBoolPtr(v))
+		}
+	case pgtype.Int8:
+		if opts.has("asstring") {
+			return setStringOrPtr(field, strconv.FormatInt(v.Int64, 10), !v.Valid)
+		}
+		if unit, ok := opts["duration"]; ok {
+			return setDurationOption(field, v.Int64, !v.Valid, unit)
+		}
+	case int64:
+		if unit, ok := opts["duration"]; ok {
+			return setDurationOption(field, v, false, unit)
+		}
+	case time.Duration:
+		if opts.has("duration") {
+			return setDurationOption(field, int64(v), false, "ns")
+		}
+	case pgtype.Numeric:
+		if raw, ok := opts["scale"]; ok {
+			scale, err := strconv.Atoi(raw)
+			if err != nil {
+				return false, fmt.Errorf("invalid scale %q", raw)
+			}
+			str, err := PgNumericToDecimalString(v, scale)
+			if err != nil {
+				return false, err
+			}
+			return setStringOrPtr(field, str, !v.Valid)
+		}
+		if mode, ok := opts["int"]; ok {
+			if mode != "" && mode != "trunc" {
+				return false, fmt.Errorf("invalid int mode %q", mode)
+			}
+			i, err := numericToInt64Ptr(v, mode == "trunc")
+			if err != nil {
+				return false, err
+			}
+			if i == nil {
+				return setIntOrPtr(field, 0, true)
+			}
+			return setIntOrPtr(field, *i, false)
+		}
+	case pgtype.Float8:
+		if opts.has("round") {
+			i := PgFloat8ToInt64Ptr(v)
+			if i == nil && v.Valid && cfg.strict {
+				return false, fmt.Errorf("cannot round %v to an integer", v.Float64)
+			}
+			if i == nil {
+				return setIntOrPtr(field, 0, true)
+			}
+			return setIntOrPtr(field, *i, false)
+		}
+		if raw, ok := opts["prec"]; ok {
+			prec := -1
+			if raw != "" {
+				var err error
+				if prec, err = strconv.Atoi(raw); err != nil {
+					return false, fmt.Errorf("invalid prec %q", raw)
+				}
+			}
+			str, valid := PgFloat8ToDecimalString(v, prec)
+			return setStringOrPtr(field, str, !valid)
+		}
+	case pgtype.Date:
+		if layout, ok := opts["layout"]; ok {
+			return setStringOrPtr(field, formatDate(v, layout), PgDateToTimePtr(v) == nil)
+		}
+	}
+	return false, nil
+}
+
+func setTimestamptzOption(field reflect.Value, ts pgtype.Timestamptz, opts tagOptions, cfg *config) (bool, error) {
+	t := PgTimestamptzToTimePtr(ts)
+	switch {
+	case opts.has("age"):
+		age := PgTimestamptzToAgeString(ts, cfg.timeNow())
+		if age == nil {
+			return setStringOrPtr(field, "", true)
+		}
+		return setStringOrPtr(field, *age, false)
+	case opts.has("epoch"), opts.has("epochmillis"), opts.has("nano"):
+		var v int64
+		if t != nil {
+			switch {
+			case opts.has("nano"):
+				v = t.UnixNano()
+			case opts.has("epochmillis"):
+				v = t.UnixMilli()
+			default:
+				v = t.Unix()
+			}
+		}
+		return setIntOrPtr(field, v, t == nil)
+	case opts.has("rfc3339nano"):
+		if t == nil {
+			return setStringOrPtr(field, "", true)
+		}
+		return setStringOrPtr(field, t.Format(time.RFC3339Nano), false)
+	case opts.has("layout"):
+		if t == nil {
+			return setStringOrPtr(field, "", true)
+		}
+		return setStringOrPtr(field, t.Format(opts["layout"]), false)
+	}
+	return false, nil
+}
+
+// setIntOrPtr stores v into an integer field or a pointer-to-integer field;
+// null leaves the int zero and the pointer nil.
+func setIntOrPtr(field reflect.Value, v int64, null bool) (bool, error) {
+	switch {
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		field.SetInt(v)
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() >= reflect.Int && field.Type().Elem().Kind() <= reflect.Int64:
+		if null {
+			field.Set(reflect.Zero(field.Type()))
+			return true, nil
+		}
+		p := reflect.New(field.Type().Elem())
+		p.Elem().SetInt(v)
+		field.Set(p)
+	default:
+		return false, fmt.Errorf("tag option needs an integer field, got %s", field.Type())
+	}
+	return true, nil
+}
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	durationPtrType = reflect.TypeOf((*time.Duration)(nil))
+
+	durationUnits = map[string]time.Duration{
+		"":   time.Nanosecond,
+		"ns": time.Nanosecond,
+		"us": time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+	}
+)
+
+// setDurationOption handles the duration tag option: n counts units of unit
+// (nanoseconds unless the tag says `duration=ms` etc.) and is stored as a
+// time.Duration or formatted with Duration.String.
+func setDurationOption(field reflect.Value, n int64, null bool, unit string) (bool, error) {
+	scale, ok := durationUnits[unit]
+	if !ok {
+		return false, fmt.Errorf("invalid duration unit %q", unit)
+	}
+	d := time.Duration(n) * scale
+	switch field.Type() {
+	case durationType:
+		field.SetInt(int64(d))
+	case durationPtrType:
+		if null {
+			field.Set(reflect.Zero(field.Type()))
+		} else {
+			field.Set(reflect.ValueOf(&d))
+		}
+	default:
+		return setStringOrPtr(field, d.String(), null)
+	}
+	return true, nil
+}
+
+// intDBValue reads an integer pgtype or plain integer db value.
+func intDBValue(v reflect.Value) (n int64, valid, ok bool) {
+	if n, valid, ok := pgIntValue(v); ok {
+		return n, valid, true
+	}
+	if isSignedIntKind(v.Kind()) {
+		return v.Int(), true, true
+	}
+	return 0, false, false
+}
+
+// setTimeOrPtr stores t into a time.Time or *time.Time field; null leaves
+// the time zero and the pointer nil.
+func setTimeOrPtr(field reflect.Value, t time.Time, null bool) bool {
+	switch {
+	case null:
+		field.Set(reflect.Zero(field.Type()))
+	case field.Type() == timePtrType:
+		field.Set(reflect.ValueOf(&t))
+	default:
+		field.Set(reflect.ValueOf(t))
+	}
+	return true
+}
+
+// setBoolOrPtr stores b into a bool or *bool field; nil leaves the bool false
+// and the pointer nil.
+func setBoolOrPtr(field reflect.Value, b *bool) (bool, error) {
+	switch {
+	case field.Kind() == reflect.Bool:
+		field.SetBool(b != nil && *b)
+	case isPtrTo(field.Type(), reflect.Bool):
+		if b == nil {
+			field.Set(reflect.Zero(field.Type()))
+			return true, nil
+		}
+		p := reflect.New(field.Type().Elem())
+		p.Elem().SetBool(*b)
+		field.Set(p)
+	default:
+		return false, fmt.Errorf("tag option needs a bool field, got %s", field.Type())
+	}
+	return true, nil
+}
+
+func isStringTarget(t reflect.Type) bool {
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.String)
+}
+
+// setStringOrPtr is setIntOrPtr for string and *string fields.
+func setStringOrPtr(field reflect.Value, s string, null bool) (bool, error) {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(s)
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.String:
+		if null {
+			field.Set(reflect.Zero(field.Type()))
+			return true, nil
+		}
+		p := reflect.New(field.Type().Elem())
+		p.Elem().SetString(s)
+		field.Set(p)
+	default:
+		return false, fmt.Errorf("tag option needs a string field, got %s", field.Type())
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package sqlcmapper
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseDBTag(t *testing.T) {
+	for _, tc := range []struct {
+		tag  string
+		name string
+		opts tagOptions
+	}{
+		{"", "", nil},
+		{"name", "name", nil},
+		{"name,omitempty", "name", tagOptions{"omitempty": ""}},
+		{"ends_at, layout=2006-01-02 ,epoch", "ends_at", tagOptions{"layout": "2006-01-02", "epoch": ""}},
+		{",asstring", "", tagOptions{"asstring": ""}},
+		{"-", "-", nil},
+		{"-,", "-", tagOptions{}},
+	} {
+		name, opts := parseDBTag(tc.tag)
+		if name != tc.name || !reflect.DeepEqual(opts, tc.opts) {
+			t.Errorf("parseDBTag(%q) = %q, %v; want %q, %v", tc.tag, name, opts, tc.name, tc.opts)
+		}
+	}
+}
+
+type commaTagRow struct {
+	FullName pgtype.Text
+	Email    string
+}
+
+type commaTagModel struct {
+	Name  string `db:"full_name,omitempty"`
+	Email string `db:",omitempty"`
+}
+
+func TestAutoMapCommaTag(t *testing.T) {
+	row := commaTagRow{FullName: pgtype.Text{String: "Ada", Valid: true}, Email: "a@b.c"}
+	got, err := AutoMapWithTags[commaTagRow, commaTagModel](row, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (commaTagModel{Name: "Ada", Email: "a@b.c"}) {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+type tagHandlerRow struct {
+	Amount pgtype.Numeric
+	Code   pgtype.Text
+}
+
+type tagHandlerModel struct {
+	Amount string `db:"amount,scale=2"`
+	Code   string `db:"code,pad=5"`
+}
+
+func padHandler(opt string, dbVal any, dst reflect.Value) error {
+	width, err := strconv.Atoi(opt)
+	if err != nil {
+		return err
+	}
+	s := dbVal.(pgtype.Text).String
+	for len(s) < width {
+		s = "0" + s
+	}
+	dst.SetString(s)
+	return nil
+}
+
+func TestWithTagOption(t *testing.T) {
+	row := tagHandlerRow{Amount: numeric(t, "1.5"), Code: pgtype.Text{String: "42", Valid: true}}
+	got, err := AutoMapWithTags[tagHandlerRow, tagHandlerModel](row, WithTagOption("pad", padHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (tagHandlerModel{Amount: "1.50", Code: "00042"}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	// Under WithStrict an unregistered option is an error, a registered one
+	// is known.
+	if _, err := AutoMapWithTags[tagHandlerRow, tagHandlerModel](row, WithStrict()); err == nil || !strings.Contains(err.Error(), "unknown tag option pad") {
+		t.Fatalf("strict without handler: err = %v, want an unknown tag option error", err)
+	}
+	if _, err := AutoMapWithTags[tagHandlerRow, tagHandlerModel](row, WithStrict(), WithTagOption("pad", padHandler)); err != nil {
+		t.Fatalf("strict with handler: %v", err)
+	}
+}
+
+func TestWithTagOptionOverridesBuiltin(t *testing.T) {
+	row := tagHandlerRow{Amount: numeric(t, "1.5")}
+	scale := WithTagOption("scale", func(opt string, dbVal any, dst reflect.Value) error {
+		dst.SetString("custom " + opt)
+		return nil
+	})
+	got, err := AutoMapWithTags[tagHandlerRow, tagHandlerModel](row, scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != "custom 2" {
+		t.Fatalf("Amount = %q, want the custom scale handler", got.Amount)
+	}
+}
+
+func TestWithTagOptionHandlerError(t *testing.T) {
+	type model struct {
+		Code string `db:"code,pad=x"`
+	}
+	_, err := AutoMapWithTags[tagHandlerRow, model](tagHandlerRow{}, WithTagOption("pad", padHandler))
+	var mapErr *MapError
+	var numErr *strconv.NumError
+	if !errors.As(err, &mapErr) || mapErr.Field != "Code" || !errors.As(err, &numErr) {
+		t.Fatalf("err = %v, want a MapError for Code wrapping the handler error", err)
+	}
+}
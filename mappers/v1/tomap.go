@@ -0,0 +1,342 @@
+package sqlcmapper
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/////////////////////
+// Struct -> map helpers
+/////////////////////
+
+// FieldPair is one column of a db struct in declaration order.
+type FieldPair struct {
+	Key   string
+	Value any
+}
+
+// AutoMapToMap turns a db struct into a column-keyed map, unwrapping pgtype
+// values into plain Go values (nil for NULL). Keys follow the same rules as
+// ModelToMap. Nested structs become nested maps and slices of structs become
+// []map[string]any, so the result holds no pgtype values and can be handed
+// straight to encoding/json.
+func AutoMapToMap(dbStruct any) map[string]any {
+	pairs := AutoMapToOrderedPairs(dbStruct)
+	if pairs == nil {
+		return nil
+	}
+	out := make(map[string]any, len(pairs))
+	for _, p := range pairs {
+		out[p.Key] = p.Value
+	}
+	return out
+}
+
+// AutoMapToOrderedPairs is AutoMapToMap preserving struct field order, for
+// deterministic output.
+func AutoMapToOrderedPairs(dbStruct any) []FieldPair {
+	v := reflect.ValueOf(dbStruct)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	out := make([]FieldPair, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := modelColumnName(sf)
+		if key == "-" {
+			continue
+		}
+		out = append(out, FieldPair{Key: key, Value: unwrapPgValue(v.Field(i))})
+	}
+	return out
+}
+
+var (
+	driverValuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	pgtypePkgPath    = reflect.TypeOf(pgtype.Text{}).PkgPath()
+)
+
+// unwrapPgValue converts a pgtype value into its plain Go equivalent, or nil
+// when it is NULL. Structs, pointers and slices are unwrapped recursively;
+// other values are returned unchanged.
+func unwrapPgValue(v reflect.Value) any {
+	if v.Type().PkgPath() != pgtypePkgPath {
+		if out, ok := unwrapNested(v); ok {
+			return out
+		}
+	}
+	switch x := v.Interface().(type) {
+	case pgtype.UUID:
+		if !x.Valid {
+			return nil
+		}
+		return PgUUIDToString(x)
+	case pgtype.Timestamptz:
+		if t := PgTimestamptzToTimePtr(x); t != nil {
+			return *t
+		}
+		return nil
+	case pgtype.Date:
+		if t := PgDateToTimePtr(x); t != nil {
+			return *t
+		}
+		return nil
+	}
+	if v.Type().PkgPath() != pgtypePkgPath {
+		return v.Interface()
+	}
+	if v.Kind() == reflect.Struct {
+		if elems := v.FieldByName("Elements"); elems.IsValid() && elems.Kind() == reflect.Slice {
+			if isNullDBValue(v) {
+				return nil
+			}
+			out := make([]any, elems.Len())
+			for i := range out {
+				out[i] = unwrapPgValue(elems.Index(i))
+			}
+			return out
+		}
+	}
+	if v.Type().Implements(driverValuerType) {
+		if dv, err := v.Interface().(driver.Valuer).Value(); err == nil {
+			return dv
+		}
+	}
+	return v.Interface()
+}
+
+// unwrapNested handles the non-pgtype containers AutoMapToMap descends into:
+// pointers, structs with exported fields (time.Time and opaque values such as
+// netip.Prefix are left alone) and slices or arrays of anything that itself
+// needs unwrapping. []byte is kept as is.
+func unwrapNested(v reflect.Value) (any, bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, true
+		}
+		return unwrapPgValue(v.Elem()), true
+	case reflect.Struct:
+		if !isRecordStruct(v.Type()) {
+			return nil, false
+		}
+		pairs := AutoMapToOrderedPairs(v.Interface())
+		out := make(map[string]any, len(pairs))
+		for _, p := range pairs {
+			out[p.Key] = p.Value
+		}
+		return out, true
+	case reflect.Slice, reflect.Array:
+		elem := v.Type().Elem()
+		if !needsUnwrap(elem) {
+			return nil, false
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, true
+		}
+		if isRecordStruct(elem) || (elem.Kind() == reflect.Ptr && isRecordStruct(elem.Elem())) {
+			out := make([]map[string]any, v.Len())
+			for i := range out {
+				out[i], _ = unwrapPgValue(v.Index(i)).(map[string]any)
+			}
+			return out, true
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = unwrapPgValue(v.Index(i))
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// isRecordStruct reports whether t is a plain struct AutoMapToMap should turn
+// into a nested map.
+func isRecordStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType || t.PkgPath() == pgtypePkgPath {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+func needsUnwrap(t reflect.Type) bool {
+	switch {
+	case t.PkgPath() == pgtypePkgPath:
+		return true
+	case t.Kind() == reflect.Ptr:
+		return true
+	case t.Kind() == reflect.Struct:
+		return isRecordStruct(t)
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return t.Elem().Kind() != reflect.Uint8 && needsUnwrap(t.Elem())
+	}
+	return false
+}
+
+// ModelToMap flattens a model into a column-keyed map, e.g. for structured
+// logging. Keys come from the db tag, then the json tag, then the snake_case
+// field name. Pointers are dereferenced (nil stays nil), Optional values
+// become their value (nil unless Valid) and nested structs become nested
+// maps. time.Time, pgtype values and structs without exported fields, such
+// as netip.Prefix, are kept as values.
+func ModelToMap(model any) map[string]any {
+	v := reflect.ValueOf(model)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return modelValueToMap(v)
+}
+
+func modelValueToMap(v reflect.Value) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := modelColumnName(sf)
+		if key == "-" {
+			continue
+		}
+		out[key] = modelValueToAny(v.Field(i))
+	}
+	return out
+}
+
+func modelValueToAny(v reflect.Value) any {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if _, ok := optionalValueType(v.Type()); ok {
+		if !v.Field(2).Bool() {
+			return nil
+		}
+		return modelValueToAny(v.Field(0))
+	}
+	if isRecordStruct(v.Type()) {
+		return modelValueToMap(v)
+	}
+	return v.Interface()
+}
+
+// modelColumnName picks the column key for a model field: db tag, json tag,
+// then snake_case of the Go name. Tag options after a comma are ignored.
+func modelColumnName(sf reflect.StructField) string {
+	for _, key := range []string{"db", "json"} {
+		if tag := sf.Tag.Get(key); tag != "" {
+			if name, _, _ := strings.Cut(tag, ","); name != "" {
+				return name
+			}
+		}
+	}
+	return toSnakeCase(sf.Name)
+}
+
+// ModelToStringSlice renders the given columns of model in order, e.g. as a
+// CSV record. Columns are resolved as in ModelToMap; missing columns and nil
+// values give "". Times use RFC 3339; see ModelToStringSliceWith to change
+// the layout.
+func ModelToStringSlice(model any, columns ...string) []string {
+	return ModelToStringSliceWith(model, columns)
+}
+
+// ModelToStringSliceWith is ModelToStringSlice with options; times use the
+// WithTimeLayout layout.
+func ModelToStringSliceWith(model any, columns []string, opts ...Option) []string {
+	cfg := newConfig(opts)
+	values := ModelToMap(model)
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		out[i] = stringifyValue(values[col], cfg.timeLayout)
+	}
+	return out
+}
+
+func stringifyValue(v any, timeLayout string) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		return x.Format(timeLayout)
+	case fmt.Stringer:
+		return x.String()
+	case []byte:
+		return string(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// FieldChange is one differing field reported by Diff.
+type FieldChange struct {
+	Old, New any
+}
+
+// Diff compares two models field by field, keyed like ModelToMap, e.g. for an
+// audit trail. Pointers are dereferenced and nested structs contribute dotted
+// keys ("address.city"); a nil nested pointer compares as nil against each
+// key of the other side. Equal fields are left out.
+func Diff[T any](a, b T) map[string]FieldChange {
+	out := make(map[string]FieldChange)
+	diffMaps(out, "", ModelToMap(a), ModelToMap(b))
+	return out
+}
+
+func diffMaps(out map[string]FieldChange, prefix string, before, after map[string]any) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	for key := range keys {
+		old, nv := before[key], after[key]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		oldMap, oldNested := old.(map[string]any)
+		newMap, newNested := nv.(map[string]any)
+		switch {
+		case oldNested && newNested:
+			diffMaps(out, key, oldMap, newMap)
+		case oldNested && nv == nil:
+			diffMaps(out, key, oldMap, nil)
+		case newNested && old == nil:
+			diffMaps(out, key, nil, newMap)
+		case !reflect.DeepEqual(old, nv):
+			out[key] = FieldChange{Old: old, New: nv}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package sqlcmapper
+
+import (
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type toMapAddr struct {
+	City string `db:"city"`
+}
+
+type toMapModel struct {
+	ID      int64 `db:"id"`
+	Name    *string
+	Net     netip.Prefix `json:"net"`
+	Amount  pgtype.Numeric
+	Created time.Time
+	Addr    *toMapAddr `db:"addr"`
+	Secret  string     `db:"-"`
+}
+
+func TestModelToMap(t *testing.T) {
+	name := "n"
+	net := netip.MustParsePrefix("10.0.0.0/8")
+	amount := pgtype.Numeric{Int: bigInt(125), Exp: -2, Valid: true}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := toMapModel{ID: 1, Name: &name, Net: net, Amount: amount, Created: created, Addr: &toMapAddr{City: "c"}, Secret: "s"}
+
+	got := ModelToMap(m)
+	want := map[string]any{
+		"id":      int64(1),
+		"name":    "n",
+		"net":     net,
+		"amount":  amount,
+		"created": created,
+		"addr":    map[string]any{"city": "c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+
+	got = ModelToMap(&toMapModel{})
+	if got["name"] != nil || got["addr"] != nil {
+		t.Fatalf("nil pointers should map to nil, got %#v", got)
+	}
+}
+
+func bigInt(n int64) *big.Int { return big.NewInt(n) }
+
+func TestDiff(t *testing.T) {
+	before := toMapModel{ID: 1, Net: netip.MustParsePrefix("10.0.0.0/8"), Addr: &toMapAddr{City: "a"}}
+	after := toMapModel{ID: 1, Net: netip.MustParsePrefix("192.168.0.0/16"), Addr: &toMapAddr{City: "b"}}
+	got := Diff(before, after)
+	want := map[string]FieldChange{
+		"net":       {Old: before.Net, New: after.Net},
+		"addr.city": {Old: "a", New: "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+
+	if got := Diff(before, before); len(got) != 0 {
+		t.Fatalf("equal models differ: %#v", got)
+	}
+}
+
+func TestDiffNilNestedPointer(t *testing.T) {
+	populated := toMapModel{Addr: &toMapAddr{City: "c"}}
+	want := map[string]FieldChange{"addr.city": {Old: nil, New: "c"}}
+	if got := Diff(toMapModel{}, populated); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nil -> populated: got %#v, want %#v", got, want)
+	}
+	want = map[string]FieldChange{"addr.city": {Old: "c", New: nil}}
+	if got := Diff(populated, toMapModel{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("populated -> nil: got %#v, want %#v", got, want)
+	}
+}
+
+func TestModelToStringSlice(t *testing.T) {
+	name := "n"
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := toMapModel{ID: 7, Name: &name, Net: netip.MustParsePrefix("10.0.0.0/8"), Created: created}
+	got := ModelToStringSlice(m, "id", "name", "net", "created", "addr", "missing")
+	want := []string{"7", "n", "10.0.0.0/8", "2024-01-02T03:04:05Z", "", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	got = ModelToStringSliceWith(&m, []string{"created"}, WithTimeLayout("2006-01-02"))
+	if got[0] != "2024-01-02" {
+		t.Fatalf("created = %q, want the WithTimeLayout layout", got[0])
+	}
+
+	if got := ModelToStringSlice(m); len(got) != 0 {
+		t.Fatalf("no columns: got %q, want an empty record", got)
+	}
+}